@@ -0,0 +1,86 @@
+package client
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+)
+
+type benchSpan struct {
+	Duration int64    `json:"duration"`
+	Service  string   `json:"service"`
+	Status   int32    `json:"status"`
+	Latency  float64  `json:"latency"`
+	Error    bool     `json:"error"`
+	Tags     []string `json:"tags"`
+}
+
+func newBenchRecord(rows int) arrow.Record {
+	schema, _ := DeriveArrowSchema(benchSpan{}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	services := []string{"frontend", "checkout", "payment", "shipping"}
+	for i := 0; i < rows; i++ {
+		builder.Field(0).(*array.Int64Builder).Append(int64(i))
+		builder.Field(1).(*array.StringBuilder).Append(services[i%len(services)])
+		builder.Field(2).(*array.Int32Builder).Append(int32(200 + i%3))
+		builder.Field(3).(*array.Float64Builder).Append(float64(i) / 3)
+		builder.Field(4).(*array.BooleanBuilder).Append(i%7 == 0)
+		tags := builder.Field(5).(*array.ListBuilder)
+		tags.Append(true)
+		tags.ValueBuilder().(*array.StringBuilder).Append(strconv.Itoa(i % 10))
+	}
+	return builder.NewRecord()
+}
+
+func BenchmarkUnmarshalRecord(b *testing.B) {
+	record := newBenchRecord(1_000_000)
+	defer record.Release()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var spans []benchSpan
+		if err := UnmarshalRecord(record, &spans); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalRecordParallel(b *testing.B) {
+	record := newBenchRecord(1_000_000)
+	defer record.Release()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var spans []benchSpan
+		if err := UnmarshalRecordWithOptions(record, &spans, DecodeParallel(0, 0)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalColumns(b *testing.B) {
+	record := newBenchRecord(1_000_000)
+	defer record.Release()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var cols struct {
+			Duration []int64   `json:"duration"`
+			Service  []string  `json:"service"`
+			Status   []int32   `json:"status"`
+			Latency  []float64 `json:"latency"`
+			Error    []bool    `json:"error"`
+		}
+		if err := UnmarshalColumns(record, &cols); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
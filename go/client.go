@@ -4,19 +4,25 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 
+	"github.com/apache/arrow/go/v10/arrow"
 	"github.com/apache/arrow/go/v10/arrow/flight"
 	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/chronowave/client/go/internal/decode"
 	"github.com/chronowave/codec"
 )
 
 type Client struct {
 	clt flight.Client
+	opt QueryOption
 }
 
 func New(uri string) (*Client, error) {
@@ -26,6 +32,19 @@ func New(uri string) (*Client, error) {
 	}, err
 }
 
+// WithQueryOptions returns a client sharing the connection of c whose queries
+// use the options of c changed by optFuncs.
+func (c *Client) WithQueryOptions(optFuncs ...QueryOptionFunc) *Client {
+	clt := &Client{clt: c.clt, opt: c.opt}
+	for _, optFunc := range optFuncs {
+		optFunc(&clt.opt)
+	}
+	return clt
+}
+
+// CreateFlight creates the flight named name. schema is its arrow schema
+// serialized by flight.SerializeSchema, which annotates the columns of
+// extension types with their name and metadata.
 func (c *Client) CreateFlight(ctx context.Context, name string, schema []byte) error {
 	body, err := proto.Marshal(&codec.FlightSchemaRequest{
 		Flight: name,
@@ -49,36 +68,256 @@ func (c *Client) CreateFlight(ctx context.Context, name string, schema []byte) e
 	return err
 }
 
-// Query returns JSON document
-func (c *Client) Query(ctx context.Context, qry string, v any) error {
-	get, err := c.clt.DoGet(ctx, &flight.Ticket{Ticket: []byte(qry)})
+// GetSchema returns the schema of the flight named flightName.
+func (c *Client) GetSchema(ctx context.Context, flightName string) (*arrow.Schema, error) {
+	res, err := c.clt.GetSchema(ctx, &flight.FlightDescriptor{
+		Type: flight.DescriptorPATH,
+		Path: []string{flightName},
+	})
 	if err != nil {
+		return nil, err
+	}
+	return flight.DeserializeSchema(res.GetSchema(), memory.DefaultAllocator)
+}
+
+// Query decodes the first record answering qry into v as
+// UnmarshalRecordWithOptions does.
+func (c *Client) Query(ctx context.Context, qry string, v any, optFuncs ...DecodeOptionFunc) error {
+	record, err := c.query(ctx, c.projectQuery(ctx, qry, v))
+	if err != nil || record == nil {
 		return err
 	}
+	defer record.Release()
+	return UnmarshalRecordWithOptions(record, v, optFuncs...)
+}
+
+// QueryZeroCopy is Query decoding strings and byte slices without copying
+// them, as UnmarshalRecordZeroCopy does. They are valid until the returned
+// RecordRef is released.
+func (c *Client) QueryZeroCopy(ctx context.Context, qry string, v any, optFuncs ...DecodeOptionFunc) (*RecordRef, error) {
+	record, err := c.query(ctx, c.projectQuery(ctx, qry, v))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return &RecordRef{}, nil
+	}
+	defer record.Release()
+	return UnmarshalRecordZeroCopy(record, v, optFuncs...)
+}
+
+// projectQuery returns qry finding only the columns decoded into v when c
+// projects columns, else qry as is.
+func (c *Client) projectQuery(ctx context.Context, qry string, v any) string {
+	if !c.opt.ProjectColumns {
+		return qry
+	}
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return qry
+	}
+	find, ok := parseFind(qry)
+	if !ok || find.flight == "" {
+		return qry
+	}
+	// a query of an unknown flight fails by itself
+	schema, err := c.GetSchema(ctx, find.flight)
+	if err != nil {
+		return qry
+	}
+	fields, ok := fieldsAt(schema.Fields(), find.path)
+	if !ok {
+		return qry
+	}
+	columns, err := decode.MatchedColumns(t, fields)
+	if err != nil || len(columns) == 0 {
+		return qry
+	}
+	return find.project(qry, columns)
+}
+
+// findQuery matches the FIND clause of a query finding one variable, and the
+// flight of its FROM clause.
+var findQuery = regexp.MustCompile(`(?i)^\s*find\s+(\$[a-z_][a-z0-9_.\-]*)\s+(?:from\s+([a-z_][a-z0-9_.\-]*)\s+)?where\b`)
+
+// findClause locates the parts of a query finding one variable which
+// projection rewrites.
+type findClause struct {
+	flight           string // the flight of the FROM clause, "" without it
+	variable         string // the variable found, with its $
+	varStart, varEnd int    // the variable in the FIND clause
+	path             string // the path the variable is bound to
+	pathEnd          int    // the end of the path in the vector binding it
+}
+
+// parseFind parses the FIND clause of qry and the first vector binding the
+// variable found to a path. It fails for queries finding anything else than
+// one variable so bound, whose vector holds no predicate.
+func parseFind(qry string) (*findClause, bool) {
+	m := findQuery.FindStringSubmatchIndex(qry)
+	if m == nil {
+		return nil, false
+	}
+	find := &findClause{variable: qry[m[2]:m[3]], varStart: m[2], varEnd: m[3]}
+	if m[4] >= 0 {
+		find.flight = qry[m[4]:m[5]]
+	}
+	for _, start := range variableIndices(qry[m[1]:], find.variable) {
+		start += m[1]
+		if !strings.HasSuffix(strings.TrimRight(qry[m[1]:start], " \t\r\n"), "[") {
+			continue
+		}
+		i := start + len(find.variable)
+		for i < len(qry) && isSpace(qry[i]) {
+			i++
+		}
+		if i == len(qry) || qry[i] != '/' {
+			return nil, false
+		}
+		pathStart := i
+		for i < len(qry) && (qry[i] == '/' || isNameByte(qry[i])) {
+			i++
+		}
+		rest := strings.TrimLeft(qry[i:], " \t\r\n")
+		if rest == "" || rest[0] != '[' && rest[0] != ']' {
+			return nil, false
+		}
+		find.path, find.pathEnd = qry[pathStart:i], i
+		return find, true
+	}
+	return nil, false
+}
+
+// project returns qry finding columns rather than the documents of its
+// variable: each column is bound to the field of its name in a vector nested
+// in the one of the variable, so that
+//
+//	find $a from spans where [$a /spans [/status eq(500)]]
+//
+// projected onto name and status becomes
+//
+//	find $name, $status from spans where [$a /spans [$name /name] [$status /status] [/status eq(500)]]
+//
+// and the server answers with one column per variable. qry is returned as is
+// when a column is not an SSQL name or is already a variable of qry.
+func (f *findClause) project(qry string, columns []string) string {
+	var find, bind strings.Builder
+	for i, column := range columns {
+		if !isName(column) || len(variableIndices(qry, "$"+column)) > 0 {
+			return qry
+		}
+		if i > 0 {
+			find.WriteString(", ")
+		}
+		find.WriteString("$" + column)
+		bind.WriteString(" [$" + column + " /" + column + "]")
+	}
+	return qry[:f.varStart] + find.String() + qry[f.varEnd:f.pathEnd] + bind.String() + qry[f.pathEnd:]
+}
+
+// fieldsAt returns the fields of the structs at path in documents of the
+// fields of a flight schema, lists standing for their elements.
+func fieldsAt(fields []arrow.Field, path string) ([]arrow.Field, bool) {
+	for _, step := range strings.Split(path, "/") {
+		if step == "" {
+			continue
+		}
+		var dt arrow.DataType
+		for _, f := range fields {
+			if f.Name == step {
+				dt = f.Type
+				break
+			}
+		}
+		for {
+			if _, ok := dt.(*arrow.MapType); ok {
+				return nil, false
+			}
+			list, ok := dt.(interface{ Elem() arrow.DataType })
+			if !ok {
+				break
+			}
+			dt = list.Elem()
+		}
+		st, ok := dt.(*arrow.StructType)
+		if !ok {
+			return nil, false
+		}
+		fields = st.Fields()
+	}
+	return fields, true
+}
+
+// variableIndices returns the indices in qry of the SSQL variable name.
+func variableIndices(qry, name string) []int {
+	var indices []int
+	for i := 0; ; {
+		j := strings.Index(qry[i:], name)
+		if j < 0 {
+			return indices
+		}
+		i += j + len(name)
+		if i == len(qry) || !isNameByte(qry[i]) {
+			indices = append(indices, i-len(name))
+		}
+	}
+}
+
+// isName reports whether s is an SSQL name: a letter or underscore followed
+// by letters, digits, underscores, dots and dashes.
+func isName(s string) bool {
+	if s == "" || '0' <= s[0] && s[0] <= '9' || s[0] == '.' || s[0] == '-' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isNameByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b == '_' || b == '.' || b == '-'
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+// query returns the first record answering qry, nil when there is none. The
+// caller releases the record.
+func (c *Client) query(ctx context.Context, qry string) (arrow.Record, error) {
+	get, err := c.clt.DoGet(ctx, &flight.Ticket{Ticket: []byte(qry)})
+	if err != nil {
+		return nil, err
+	}
 
 	resp, err := get.Recv()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	reader, err := ipc.NewReader(bytes.NewReader(resp.DataBody))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer reader.Release()
 
 	err = reader.Err()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for reader.Next() {
+	if reader.Next() {
 		record := reader.Record()
-		defer record.Release()
-		return UnmarshalRecord(record, v)
+		record.Retain()
+		return record, nil
 	}
-
-	return nil
+	return nil, reader.Err()
 }
 
 func (c *Client) UploadData(ctx context.Context, flightName string, data []byte) error {
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/flight"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startFlightServer serves srv on a local port and returns a client of it,
+// and a function stopping both.
+func startFlightServer(srv flight.FlightServer) (*Client, func(), error) {
+	server := flight.NewServerWithMiddleware(nil)
+	if err := server.Init("localhost:0"); err != nil {
+		return nil, nil, err
+	}
+	server.RegisterFlightService(srv)
+	go server.Serve()
+
+	clt, err := New(server.Addr().String())
+	if err != nil {
+		server.Shutdown()
+		return nil, nil, err
+	}
+	return clt, func() {
+		clt.clt.Close()
+		server.Shutdown()
+	}, nil
+}
+
+// schemaServer answers GetSchema with the schemas of its flights.
+type schemaServer struct {
+	flight.BaseFlightServer
+	schemas map[string]*arrow.Schema
+}
+
+func (s *schemaServer) GetSchema(_ context.Context, desc *flight.FlightDescriptor) (*flight.SchemaResult, error) {
+	if desc.Type != flight.DescriptorPATH || len(desc.Path) != 1 || s.schemas[desc.Path[0]] == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown flight %v", desc.Path)
+	}
+	return &flight.SchemaResult{Schema: flight.SerializeSchema(s.schemas[desc.Path[0]], memory.DefaultAllocator)}, nil
+}
+
+func TestGetSchema(t *testing.T) {
+	schema, err := DeriveArrowSchema(struct {
+		TraceID string    `json:"trace_id"`
+		Start   time.Time `json:"start"`
+		Tags    []string  `json:"tags"`
+	}{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	clt, stop, err := startFlightServer(&schemaServer{schemas: map[string]*arrow.Schema{"spans": schema}})
+	if err != nil {
+		t.Errorf("startFlightServer: %v", err)
+		return
+	}
+	defer stop()
+
+	got, err := clt.GetSchema(context.Background(), "spans")
+	if err != nil {
+		t.Errorf("GetSchema: %v", err)
+		return
+	}
+	if !got.Equal(schema) || !reflect.DeepEqual(got.Field(1).Metadata, schema.Field(1).Metadata) {
+		t.Errorf("want=%v, got=%v", schema, got)
+	}
+	if _, err := clt.GetSchema(context.Background(), "logs"); status.Code(err) != codes.NotFound {
+		t.Errorf("want NotFound for an unknown flight, got %v", err)
+	}
+}
+
+// projectionServer answers every query with the record, projected onto the
+// columns of the variables found by a query binding them to fields, and
+// keeps the last query. The documents of its traces flight are lists of the
+// rows of the record.
+type projectionServer struct {
+	flight.BaseFlightServer
+	record arrow.Record
+	mu     sync.Mutex
+	query  string
+}
+
+func (s *projectionServer) last() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.query
+}
+
+func (s *projectionServer) GetSchema(_ context.Context, desc *flight.FlightDescriptor) (*flight.SchemaResult, error) {
+	if len(desc.Path) != 1 || desc.Path[0] != "traces" {
+		return nil, status.Errorf(codes.NotFound, "flight %v not found", desc.Path)
+	}
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "traces", Type: arrow.ListOf(arrow.StructOf(s.record.Schema().Fields()...)), Nullable: true},
+	}, nil)
+	return &flight.SchemaResult{Schema: flight.SerializeSchema(schema, memory.DefaultAllocator)}, nil
+}
+
+var (
+	findVariables = regexp.MustCompile(`(?i)^\s*find\s+(.*?)\s+(?:from|where)\b`)
+	variableName  = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_.\-]*)`)
+)
+
+func (s *projectionServer) DoGet(ticket *flight.Ticket, fs flight.FlightService_DoGetServer) error {
+	qry := string(ticket.Ticket)
+	s.mu.Lock()
+	s.query = qry
+	s.mu.Unlock()
+
+	var columns []string
+	if m := findVariables.FindStringSubmatch(qry); m != nil {
+		for _, v := range variableName.FindAllStringSubmatch(m[1], -1) {
+			if strings.Contains(qry, "[$"+v[1]+" /"+v[1]+"]") && s.record.Schema().HasField(v[1]) {
+				columns = append(columns, v[1])
+			}
+		}
+	}
+
+	record := s.record
+	if len(columns) > 0 {
+		fields := make([]arrow.Field, 0, len(columns))
+		cols := make([]arrow.Array, 0, len(columns))
+		for _, name := range columns {
+			for _, idx := range record.Schema().FieldIndices(name) {
+				fields = append(fields, record.Schema().Field(idx))
+				cols = append(cols, record.Column(idx))
+			}
+		}
+		record = array.NewRecord(arrow.NewSchema(fields, nil), cols, record.NumRows())
+		defer record.Release()
+	}
+
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(record.Schema()))
+	if err := w.Write(record); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return fs.Send(&flight.FlightData{DataBody: buf.Bytes()})
+}
+
+func TestQueryProjectColumns(t *testing.T) {
+	// fields match columns case-insensitively or by alias as well
+	type span struct {
+		Name   string `json:"Name"`
+		Status int32  `json:"code,alias=status"`
+	}
+	type traceSummary struct {
+		span
+		TraceID string `json:"trace_id"`
+		Ignored string `json:"-"`
+	}
+	type trace struct {
+		TraceID  string `json:"trace_id"`
+		Name     string `json:"name"`
+		Status   int32  `json:"status"`
+		Duration int64  `json:"duration"`
+		Service  string `json:"service"`
+	}
+
+	schema, err := DeriveArrowSchema(trace{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	record, err := MarshalRecord([]trace{
+		{TraceID: "a", Name: "get", Status: 200, Duration: 12, Service: "api"},
+		{TraceID: "b", Name: "post", Status: 500, Duration: 40, Service: "api"},
+	}, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	srv := &projectionServer{record: record}
+	clt, stop, err := startFlightServer(srv)
+	if err != nil {
+		t.Errorf("startFlightServer: %v", err)
+		return
+	}
+	defer stop()
+	ctx := context.Background()
+	want := []traceSummary{
+		{span: span{Name: "get", Status: 200}, TraceID: "a"},
+		{span: span{Name: "post", Status: 500}, TraceID: "b"},
+	}
+
+	const (
+		qry       = "FIND $a FROM traces WHERE [$a /traces [/status between(200, 599)]] limit 10"
+		projected = "FIND $trace_id, $name, $status FROM traces WHERE [$a /traces [$trace_id /trace_id] [$name /name] [$status /status] [/status between(200, 599)]] limit 10"
+	)
+	projector := clt.WithQueryOptions(ProjectColumns())
+	var rows []traceSummary
+	if err := projector.Query(ctx, qry, &rows, DisallowUnknownColumns()); err != nil {
+		t.Errorf("Query: %v", err)
+		return
+	}
+	if srv.last() != projected {
+		t.Errorf("unexpected projected query: %s", srv.last())
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+
+	var ptrs []*traceSummary
+	ref, err := projector.QueryZeroCopy(ctx, qry, &ptrs)
+	if err != nil {
+		t.Errorf("QueryZeroCopy: %v", err)
+		return
+	}
+	if srv.last() != projected || len(ptrs) != 2 || *ptrs[1] != want[1] {
+		t.Errorf("unexpected zero-copy query: %s, %+v", srv.last(), ptrs)
+	}
+	ref.Release()
+
+	// without ProjectColumns, decoding no struct, or for queries finding
+	// anything else than the documents of a flight bound to one variable,
+	// the query is sent as it is
+	for _, q := range []string{
+		"find $a, $b from traces where [$a /traces] [$b /services]",
+		"find $a where [$a /traces]",
+		"find $a from spans where [$a /spans]",
+		"find $a from traces where [$a /traces/name]",
+		"find $a from traces where [$a /traces] [$status /traces/status eq(500)]",
+		"find group-by($a), count($a) from traces where [$a /traces/name]",
+	} {
+		if err := projector.Query(ctx, q, &[]traceSummary{}); err != nil {
+			t.Errorf("Query: %v", err)
+			return
+		}
+		if srv.last() != q {
+			t.Errorf("unexpected query %s: %s", q, srv.last())
+		}
+	}
+	rows = nil
+	if err := clt.Query(ctx, qry, &rows); err != nil {
+		t.Errorf("Query: %v", err)
+		return
+	}
+	if srv.last() != qry || !reflect.DeepEqual(rows, want) {
+		t.Errorf("unexpected query without projection: %s, %+v", srv.last(), rows)
+	}
+	var maps []map[string]any
+	if err := projector.Query(ctx, qry, &maps); err != nil {
+		t.Errorf("Query: %v", err)
+		return
+	}
+	if srv.last() != qry || len(maps) != 2 || len(maps[0]) != 5 {
+		t.Errorf("unexpected query into maps: %s, %v", srv.last(), maps)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/chronowave/fbs/go"
+)
+
+const (
+	arrowImport  = "github.com/apache/arrow/go/v10/arrow"
+	clientImport = "github.com/chronowave/client/go"
+)
+
+// commonInitialisms are the words written in upper case in Go names, as by golint.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true, "XMPP": true,
+	"XSRF": true, "XSS": true,
+}
+
+// dateFormat is a DateFormat entry of the generated code.
+type dateFormat struct {
+	column string
+	value  string
+}
+
+// generator writes the Go struct definitions decoding records of a schema.
+type generator struct {
+	decls       []string
+	typeNames   map[string]bool
+	imports     map[string]bool
+	dateFormats []dateFormat
+	formatted   map[string]bool
+}
+
+// generate returns the Go source of package pkg defining the struct typeName
+// for the rows of schema, with a nested type per struct column. Timestamps
+// and dates are time.Time fields whose formats are kept in the variable
+// typeName+"DateFormat", to be passed to DeriveArrowSchema.
+func generate(pkg, typeName string, schema *arrow.Schema) ([]byte, error) {
+	g := &generator{
+		typeNames: map[string]bool{},
+		imports:   map[string]bool{},
+		formatted: map[string]bool{},
+	}
+	if _, err := g.structType(typeName, schema.Fields(), ""); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by chronowave-gen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if len(g.dateFormats) > 0 {
+		g.imports[clientImport] = true
+	}
+	g.writeImports(&b)
+	for _, decl := range g.decls {
+		b.WriteString(decl)
+		b.WriteByte('\n')
+	}
+	if len(g.dateFormats) > 0 {
+		fmt.Fprintf(&b, "// %sDateFormat holds the formats of the time columns of %s.\n", typeName, typeName)
+		fmt.Fprintf(&b, "var %sDateFormat = map[string]client.DateFormat{\n", typeName)
+		for _, f := range g.dateFormats {
+			fmt.Fprintf(&b, "%s: %s,\n", strconv.Quote(f.column), f.value)
+		}
+		b.WriteString("}\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+func (g *generator) writeImports(b *bytes.Buffer) {
+	if len(g.imports) == 0 {
+		return
+	}
+	var std, others []string
+	for path := range g.imports {
+		if strings.Contains(path, ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	b.WriteString("import (\n")
+	for _, path := range std {
+		fmt.Fprintf(b, "%q\n", path)
+	}
+	if len(std) > 0 && len(others) > 0 {
+		b.WriteByte('\n')
+	}
+	for _, path := range others {
+		if path == clientImport {
+			fmt.Fprintf(b, "client %q\n", path)
+		} else {
+			fmt.Fprintf(b, "%q\n", path)
+		}
+	}
+	b.WriteString(")\n\n")
+}
+
+// structType declares the struct type name, or a free name derived from it,
+// for fields at column and returns the declared name.
+func (g *generator) structType(name string, fields []arrow.Field, column string) (string, error) {
+	name = uniqueName(name, g.typeNames)
+	// nested types are declared after the type holding them
+	decl := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	fieldNames := map[string]bool{}
+	for i, f := range fields {
+		fieldName := uniqueName(goName(f.Name, fmt.Sprintf("Field%d", i)), fieldNames)
+		typ, arrowTag, err := g.goType(f.Type, f, name+fieldName, joinColumn(column, f.Name))
+		if err != nil {
+			return "", err
+		}
+		if f.Nullable && !isNilable(typ) {
+			typ = "*" + typ
+		}
+
+		tag := "json:" + strconv.Quote(f.Name)
+		if arrowTag != "" {
+			tag += " arrow:" + strconv.Quote(arrowTag)
+		}
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		fmt.Fprintf(&b, "%s %s %s\n", fieldName, typ, tag)
+	}
+	b.WriteString("}\n")
+	g.decls[decl] = b.String()
+	return name, nil
+}
+
+// goType returns the Go type of values of dt, and the arrow tag selecting dt
+// when it is not the default column type of the Go type. f is the field of
+// the column, whose name and metadata also apply to list elements, and
+// name the name of a nested struct type.
+func (g *generator) goType(dt arrow.DataType, f arrow.Field, name, column string) (string, string, error) {
+	switch dt := dt.(type) {
+	case *arrow.NullType:
+		return "any", "", nil
+	case *arrow.BooleanType:
+		return "bool", "", nil
+	case *arrow.Int8Type, *arrow.Int16Type, *arrow.Int32Type, *arrow.Int64Type,
+		*arrow.Uint8Type, *arrow.Uint16Type, *arrow.Uint32Type, *arrow.Uint64Type,
+		*arrow.Float32Type, *arrow.Float64Type:
+		return dt.Name(), "", nil
+	case *arrow.StringType, *arrow.LargeStringType:
+		return "string", "", nil
+	case *arrow.BinaryType, *arrow.LargeBinaryType:
+		return "[]byte", "", nil
+	case *arrow.FixedSizeBinaryType:
+		return fmt.Sprintf("[%d]byte", dt.ByteWidth), "", nil
+	case *arrow.Decimal128Type:
+		g.imports[clientImport] = true
+		return "client.Decimal", fmt.Sprintf("decimal(%d,%d)", dt.Precision, dt.Scale), nil
+	case *arrow.Decimal256Type:
+		g.imports[clientImport] = true
+		return "client.Decimal", fmt.Sprintf("decimal(%d,%d)", dt.Precision, dt.Scale), nil
+	case *arrow.TimestampType:
+		g.imports["time"] = true
+		value := fmt.Sprintf("{TimeUnit: arrow.%s", unitName(dt.Unit))
+		if dt.TimeZone != "" {
+			value += fmt.Sprintf(", TimeZone: %q", dt.TimeZone)
+		}
+		if g.addDateFormat(f, value) {
+			g.imports[arrowImport] = true
+		}
+		return "time.Time", "", nil
+	case *arrow.Date32Type:
+		g.imports["time"] = true
+		g.addDateFormat(f, "{Is32Bits: true")
+		return "time.Time", "", nil
+	case *arrow.Date64Type:
+		g.imports["time"] = true
+		return "time.Time", "date64", nil
+	case *arrow.Time32Type:
+		g.imports["time"] = true
+		return "time.Duration", "time32(" + dt.Unit.String() + ")", nil
+	case *arrow.Time64Type:
+		g.imports["time"] = true
+		return "time.Duration", "time64(" + dt.Unit.String() + ")", nil
+	case *arrow.DurationType:
+		g.imports["time"] = true
+		if dt.Unit == arrow.Nanosecond {
+			return "time.Duration", "", nil
+		}
+		return "time.Duration", "duration(" + dt.Unit.String() + ")", nil
+	case *arrow.MonthDayNanoIntervalType:
+		g.imports[arrowImport] = true
+		return "arrow.MonthDayNanoInterval", "", nil
+	case *arrow.ListType:
+		elem, tag, err := g.goType(dt.Elem(), f, name, column+"[]")
+		return "[]" + elem, tag, err
+	case *arrow.LargeListType:
+		elem, tag, err := g.goType(dt.Elem(), f, name, column+"[]")
+		return "[]" + elem, tag, err
+	case *arrow.FixedSizeListType:
+		elem, tag, err := g.goType(dt.Elem(), f, name, column+"[]")
+		return fmt.Sprintf("[%d]%s", dt.Len(), elem), tag, err
+	case *arrow.MapType:
+		key, _, err := g.goType(dt.KeyType(), f, name+"Key", column+"{}")
+		if err != nil {
+			return "", "", err
+		}
+		item, tag, err := g.goType(dt.ItemType(), f, name+"Value", column+"{}")
+		return "map[" + key + "]" + item, tag, err
+	case *arrow.StructType:
+		typeName, err := g.structType(name, dt.Fields(), column)
+		return typeName, "", err
+	case arrow.UnionType:
+		// union values decode into any as the values of their children
+		return "any", "", nil
+	case arrow.ExtensionType:
+		// values of extension types are those of their storage, UUIDs
+		// included
+		return g.goType(dt.StorageType(), f, name, column)
+	}
+	return "", "", fmt.Errorf("column %s: unsupported type %v", column, dt)
+}
+
+// addDateFormat records the DateFormat of the time column f, value being its
+// composite literal without the layout and closing brace. Columns are
+// matched by name, the first of several ones of the same name winning: it
+// reports whether the entry of f was recorded.
+func (g *generator) addDateFormat(f arrow.Field, value string) bool {
+	if g.formatted[f.Name] {
+		return false
+	}
+	g.formatted[f.Name] = true
+	if i := f.Metadata.FindKey(fbs.EnumNamesMetadataKey[fbs.MetadataKeyLAYOUT]); i >= 0 {
+		value += fmt.Sprintf(", Layout: %q", f.Metadata.Values()[i])
+	}
+	g.dateFormats = append(g.dateFormats, dateFormat{column: f.Name, value: value + "}"})
+	return true
+}
+
+// goName returns the exported Go name of the column name, def when it has no
+// letter or digit.
+func goName(name, def string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	s := b.String()
+	if s == "" {
+		return def
+	}
+	if r := []rune(s)[0]; !unicode.IsLetter(r) || !unicode.IsUpper(r) {
+		// digits and letters without case do not export a name
+		s = "X" + s
+	}
+	return s
+}
+
+// uniqueName returns name, or name with the first free numeric suffix, and
+// marks it used.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// isNilable reports whether the Go type typ has a nil value for null columns.
+func isNilable(typ string) bool {
+	return typ == "any" || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[")
+}
+
+func unitName(unit arrow.TimeUnit) string {
+	return [...]string{"Second", "Millisecond", "Microsecond", "Nanosecond"}[unit]
+}
+
+func joinColumn(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/chronowave/fbs/go"
+)
+
+func TestGenerate(t *testing.T) {
+	layout := arrow.NewMetadata([]string{fbs.EnumNamesMetadataKey[fbs.MetadataKeyLAYOUT]}, []string{"2006-01-02"})
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "span_id", Type: &arrow.StringType{}},
+		{Name: "status", Type: &arrow.Int32Type{}, Nullable: true},
+		{Name: "start", Type: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}, Nullable: true},
+		{Name: "day", Type: &arrow.Date32Type{}, Metadata: layout},
+		{Name: "took", Type: &arrow.DurationType{Unit: arrow.Millisecond}},
+		{Name: "cost", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}, Nullable: true},
+		{Name: "tags", Type: arrow.ListOf(&arrow.StringType{}), Nullable: true},
+		{Name: "attrs", Type: arrow.MapOf(&arrow.StringType{}, &arrow.Float64Type{}), Nullable: true},
+		{Name: "events", Type: arrow.ListOf(arrow.StructOf(
+			arrow.Field{Name: "name", Type: &arrow.StringType{}},
+			arrow.Field{Name: "at", Type: &arrow.TimestampType{Unit: arrow.Millisecond}, Nullable: true},
+		)), Nullable: true},
+		{Name: "peer", Type: arrow.StructOf(arrow.Field{Name: "ip", Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}}), Nullable: true},
+		{Name: "2xx", Type: &arrow.BooleanType{}},
+		{Name: "Status", Type: &arrow.Uint8Type{}},
+	}, nil)
+
+	src, err := generate("spans", "Span", schema)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	// quotes stand for the backquotes of struct tags
+	want := strings.ReplaceAll(`// Code generated by chronowave-gen. DO NOT EDIT.
+
+package spans
+
+import (
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	client "github.com/chronowave/client/go"
+)
+
+type Span struct {
+	SpanID  string             'json:"span_id"'
+	Status  *int32             'json:"status"'
+	Start   *time.Time         'json:"start"'
+	Day     time.Time          'json:"day"'
+	Took    time.Duration      'json:"took" arrow:"duration(ms)"'
+	Cost    *client.Decimal    'json:"cost" arrow:"decimal(10,2)"'
+	Tags    []string           'json:"tags"'
+	Attrs   map[string]float64 'json:"attrs"'
+	Events  []SpanEvents       'json:"events"'
+	Peer    *SpanPeer          'json:"peer"'
+	X2xx    bool               'json:"2xx"'
+	Status2 uint8              'json:"Status"'
+}
+
+type SpanEvents struct {
+	Name string     'json:"name"'
+	At   *time.Time 'json:"at"'
+}
+
+type SpanPeer struct {
+	IP [16]byte 'json:"ip"'
+}
+
+// SpanDateFormat holds the formats of the time columns of Span.
+var SpanDateFormat = map[string]client.DateFormat{
+	"start": {TimeUnit: arrow.Microsecond, TimeZone: "UTC"},
+	"day":   {Is32Bits: true, Layout: "2006-01-02"},
+	"at":    {TimeUnit: arrow.Millisecond},
+}
+`, "'", "`")
+	if string(src) != want {
+		t.Errorf("want=\n%s\ngot=\n%s", want, src)
+	}
+	if err := typeCheck(src); err != nil {
+		t.Errorf("generated source does not compile: %v", err)
+	}
+
+	// date32 formats do not refer to arrow
+	src, err = generate("spans", "Span", arrow.NewSchema([]arrow.Field{
+		{Name: "day", Type: &arrow.Date32Type{}},
+	}, nil))
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	if err := typeCheck(src); err != nil {
+		t.Errorf("generated source does not compile: %v\n%s", err, src)
+	}
+
+	// float16 columns have no Go type UnmarshalRecord decodes them into
+	for _, dt := range []arrow.DataType{arrow.FixedWidthTypes.DayTimeInterval, arrow.FixedWidthTypes.Float16} {
+		if _, err := generate("spans", "Span", arrow.NewSchema([]arrow.Field{
+			{Name: "value", Type: dt},
+		}, nil)); err == nil {
+			t.Errorf("want error for unsupported column type %v", dt)
+		}
+	}
+}
+
+// typeCheck type-checks the generated source src against the export data of
+// the packages it imports.
+func typeCheck(src []byte) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "gen.go", src, 0)
+	if err != nil {
+		return err
+	}
+	lookup := func(path string) (io.ReadCloser, error) {
+		out, err := exec.Command("go", "list", "-export", "-f", "{{.Export}}", path).Output()
+		if err != nil {
+			return nil, err
+		}
+		return os.Open(strings.TrimSpace(string(out)))
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "gc", lookup)}
+	_, err = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	return err
+}
@@ -0,0 +1,131 @@
+// Command chronowave-gen writes the Go struct definitions decoding the records
+// of an Arrow schema, read from a flight of a server, an Arrow IPC file or
+// stream, or inferred from sample JSON documents:
+//
+//	chronowave-gen -type Span -server localhost:9090 -flight spans
+//	chronowave-gen -type Span -ipc spans.arrow -o span_gen.go
+//	chronowave-gen -type Span -json spans.ndjson
+//
+// Within go generate, the package defaults to the one of the file holding the
+// directive:
+//
+//	//go:generate chronowave-gen -type Span -ipc spans.arrow -o span_gen.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+
+	client "github.com/chronowave/client/go"
+)
+
+func main() {
+	var (
+		typeName   = flag.String("type", "", "name of the generated struct type")
+		pkg        = flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file, main by default")
+		output     = flag.String("o", "", "output file, stdout by default")
+		server     = flag.String("server", "", "address of the server holding the flight")
+		flightName = flag.String("flight", "", "flight whose schema is read from the server")
+		ipcFile    = flag.String("ipc", "", "Arrow IPC file or stream holding the schema")
+		jsonFile   = flag.String("json", "", "JSON documents to infer the schema from")
+		sampleSize = flag.Int("sample", 0, "number of JSON documents read, 0 for all of them")
+	)
+	flag.Parse()
+
+	if *typeName == "" {
+		fail(fmt.Errorf("missing -type"))
+	}
+	if *pkg == "" {
+		*pkg = "main"
+	}
+
+	var (
+		schema *arrow.Schema
+		err    error
+	)
+	switch {
+	case *server != "" && *flightName != "":
+		schema, err = readServerSchema(*server, *flightName)
+	case *ipcFile != "":
+		schema, err = readIPCSchema(*ipcFile)
+	case *jsonFile != "":
+		schema, err = inferJSONSchema(*jsonFile, *sampleSize)
+	default:
+		err = fmt.Errorf("missing -server and -flight, -ipc or -json")
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	src, err := generate(*pkg, *typeName, schema)
+	if err != nil {
+		fail(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*output, src, 0o644)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "chronowave-gen: %v\n", err)
+	os.Exit(1)
+}
+
+func readServerSchema(server, flightName string) (*arrow.Schema, error) {
+	clt, err := client.New(server)
+	if err != nil {
+		return nil, err
+	}
+	return clt.GetSchema(context.Background(), flightName)
+}
+
+// readIPCSchema reads the schema of an Arrow IPC file, or of a stream such as
+// a schema serialized for CreateFlight.
+func readIPCSchema(name string) (*arrow.Schema, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("ARROW1")) {
+		reader, err := ipc.NewFileReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return reader.Schema(), nil
+	}
+	reader, err := ipc.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Release()
+	return reader.Schema(), nil
+}
+
+func inferJSONSchema(name string, sampleSize int) (*arrow.Schema, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	schema, conflicts, err := client.InferSchemaFromJSON(f, client.InferSampleSize(sampleSize))
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "chronowave-gen: column %s has values of types %v, generated as string\n", c.Column, c.Types)
+	}
+	return schema, nil
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/decimal128"
+	"github.com/apache/arrow/go/v10/arrow/decimal256"
+
+	"github.com/chronowave/client/go/internal/decode"
+	"github.com/chronowave/client/go/internal/encode"
+)
+
+// DecimalUnmarshaler is implemented by types that decode an arrow decimal
+// column losslessly. DeriveArrowSchema maps them, like Decimal, big.Int,
+// big.Rat and big.Float, onto Decimal128 or Decimal256 columns.
+type DecimalUnmarshaler = decode.DecimalUnmarshaler
+
+// DecimalMarshaler is implemented by types that encode into an arrow decimal
+// column losslessly.
+type DecimalMarshaler = encode.DecimalMarshaler
+
+const (
+	// DefaultDecimalPrecision and DefaultDecimalScale describe decimal fields
+	// without an `arrow:"decimal(precision,scale)"` tag.
+	DefaultDecimalPrecision = 38
+	DefaultDecimalScale     = 9
+
+	maxDecimal128Precision = 38
+	maxDecimal256Precision = 76
+)
+
+var (
+	decimalUnmarshalerType = reflect.TypeOf((*DecimalUnmarshaler)(nil)).Elem()
+	bigIntType             = reflect.TypeOf(big.Int{})
+	bigRatType             = reflect.TypeOf(big.Rat{})
+	bigFloatType           = reflect.TypeOf(big.Float{})
+)
+
+// isDecimalType reports whether values of t are stored in decimal columns.
+func isDecimalType(t reflect.Type) bool {
+	switch t {
+	case bigIntType, bigRatType, bigFloatType:
+		return true
+	}
+	return reflect.PointerTo(t).Implements(decimalUnmarshalerType)
+}
+
+// decimalArrowType returns the decimal column type of a field tagged with opts.
+func decimalArrowType(opts arrowTagOptions) arrow.DataType {
+	precision, scale := int32(DefaultDecimalPrecision), int32(DefaultDecimalScale)
+	if opts.kind == "decimal" {
+		precision, scale = opts.precision, opts.scale
+	}
+	if precision > maxDecimal128Precision {
+		return &arrow.Decimal256Type{Precision: precision, Scale: scale}
+	}
+	return &arrow.Decimal128Type{Precision: precision, Scale: scale}
+}
+
+// Decimal is an exact fixed-point number, its value being Unscaled() * 10^-Scale().
+// The zero value is 0.
+type Decimal struct {
+	// unscaled is never mutated once set, so copies of a Decimal may share it.
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns unscaled * 10^-scale.
+func NewDecimal(unscaled *big.Int, scale int32) Decimal {
+	return Decimal{unscaled: new(big.Int).Set(unscaled), scale: scale}
+}
+
+// ParseDecimal parses a number in plain decimal notation such as "-12.3400".
+// The scale is the number of digits after the decimal point.
+func ParseDecimal(s string) (Decimal, error) {
+	var d Decimal
+	if err := d.UnmarshalText([]byte(s)); err != nil {
+		return Decimal{}, err
+	}
+	return d, nil
+}
+
+// Unscaled returns the unscaled integer value of d.
+func (d Decimal) Unscaled() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.unscaled)
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Rat returns the exact value of d.
+func (d Decimal) Rat() *big.Rat {
+	if d.scale < 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(d.Unscaled(), pow10(-d.scale)))
+	}
+	return new(big.Rat).SetFrac(d.Unscaled(), pow10(d.scale))
+}
+
+// String formats d in plain decimal notation with Scale() fractional digits.
+func (d Decimal) String() string {
+	if d.scale <= 0 {
+		return d.Rat().RatString()
+	}
+	return d.Rat().FloatString(int(d.scale))
+}
+
+// Rescale returns d with the given scale. It fails when digits would be lost.
+func (d Decimal) Rescale(scale int32) (Decimal, error) {
+	if scale >= d.scale {
+		unscaled := new(big.Int).Mul(d.Unscaled(), pow10(scale-d.scale))
+		return Decimal{unscaled: unscaled, scale: scale}, nil
+	}
+	q, r := new(big.Int).QuoRem(d.Unscaled(), pow10(d.scale-scale), new(big.Int))
+	if r.Sign() != 0 {
+		return Decimal{}, fmt.Errorf("decimal %s does not fit into scale %d", d, scale)
+	}
+	return Decimal{unscaled: q, scale: scale}, nil
+}
+
+// Decimal128 returns d as an arrow Decimal128 value of the given precision and scale.
+// It fails when d has more fractional digits than scale or does not fit into precision.
+func (d Decimal) Decimal128(precision, scale int32) (decimal128.Num, error) {
+	v, err := d.fit(precision, scale, maxDecimal128Precision)
+	if err != nil {
+		return decimal128.Num{}, err
+	}
+	return decimal128.FromBigInt(v), nil
+}
+
+// Decimal256 returns d as an arrow Decimal256 value of the given precision and scale.
+// It fails when d has more fractional digits than scale or does not fit into precision.
+func (d Decimal) Decimal256(precision, scale int32) (decimal256.Num, error) {
+	v, err := d.fit(precision, scale, maxDecimal256Precision)
+	if err != nil {
+		return decimal256.Num{}, err
+	}
+	return decimal256.FromBigInt(v), nil
+}
+
+func (d Decimal) fit(precision, scale, maxPrecision int32) (*big.Int, error) {
+	if precision < 1 || precision > maxPrecision {
+		return nil, fmt.Errorf("invalid decimal precision %d", precision)
+	}
+	r, err := d.Rescale(scale)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).Abs(r.unscaled).Cmp(pow10(precision)) >= 0 {
+		return nil, fmt.Errorf("decimal %s overflows precision %d", d, precision)
+	}
+	return r.unscaled, nil
+}
+
+// UnmarshalDecimal implements DecimalUnmarshaler.
+func (d *Decimal) UnmarshalDecimal(unscaled *big.Int, scale int32) error {
+	*d = NewDecimal(unscaled, scale)
+	return nil
+}
+
+// MarshalDecimal implements DecimalMarshaler.
+func (d Decimal) MarshalDecimal() (*big.Int, int32, error) {
+	return d.Unscaled(), d.scale, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	s := string(text)
+	digits := s
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if intPart == "" && fracPart == "" || strings.ContainsAny(intPart+fracPart, "+-") {
+		return fmt.Errorf("invalid decimal %q", s)
+	}
+	unscaled, ok := new(big.Int).SetString(s[:len(s)-len(digits)]+intPart+fracPart, 10)
+	if !ok {
+		return fmt.Errorf("invalid decimal %q", s)
+	}
+	*d = Decimal{unscaled: unscaled, scale: int32(len(fracPart))}
+	return nil
+}
+
+// pow10 returns 10^n for n >= 0.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
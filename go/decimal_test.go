@@ -0,0 +1,240 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/decimal128"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+)
+
+type invoice struct {
+	Amount Decimal   `json:"amount" arrow:"decimal(18,4)"`
+	Total  big.Int   `json:"total" arrow:"decimal(40)"`
+	Rate   *big.Rat  `json:"rate"`
+	Fees   []Decimal `json:"fees" arrow:"decimal(10,2)"`
+}
+
+func TestDeriveArrowSchemaDecimal(t *testing.T) {
+	schema, err := DeriveArrowSchema(invoice{}, nil)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	want := []arrow.DataType{
+		&arrow.Decimal128Type{Precision: 18, Scale: 4},
+		&arrow.Decimal256Type{Precision: 40, Scale: 0},
+		&arrow.Decimal128Type{Precision: DefaultDecimalPrecision, Scale: DefaultDecimalScale},
+		arrow.ListOfField(arrow.Field{Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}, Nullable: true}),
+	}
+	for i, f := range schema.Fields() {
+		if !arrow.TypeEqual(f.Type, want[i]) {
+			t.Errorf("field %s: want=%v, got=%v", f.Name, want[i], f.Type)
+		}
+	}
+}
+
+func TestDeriveArrowSchemaInvalidTag(t *testing.T) {
+	for _, v := range []any{
+		struct {
+			D Decimal `arrow:"decimal(0,0)"`
+		}{},
+		struct {
+			D Decimal `arrow:"decimal(10,12)"`
+		}{},
+		struct {
+			D Decimal `arrow:"decimal(x)"`
+		}{},
+		struct {
+			D Decimal `arrow:"money"`
+		}{},
+	} {
+		if _, err := DeriveArrowSchema(v, nil); err == nil || !strings.HasPrefix(err.Error(), "field D: ") {
+			t.Errorf("want error for %T, got %v", v, err)
+		}
+	}
+}
+
+func TestUnmarshalRecordDecimal(t *testing.T) {
+	dt := &arrow.Decimal128Type{Precision: 18, Scale: 4}
+	schema := arrow.NewSchema([]arrow.Field{{Name: "amount", Type: dt, Nullable: true}}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	builder.Field(0).(*array.Decimal128Builder).AppendValues(
+		[]decimal128.Num{decimal128.FromI64(-123456), decimal128.FromI64(20000)}, nil)
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var decimals []struct {
+		Amount Decimal `json:"amount"`
+	}
+	if err := UnmarshalRecord(record, &decimals); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if decimals[0].Amount.String() != "-12.3456" || decimals[1].Amount.String() != "2.0000" {
+		t.Errorf("unexpected decimals: %v", decimals)
+	}
+
+	var rats []struct {
+		Amount *big.Rat `json:"amount"`
+	}
+	if err := UnmarshalRecord(record, &rats); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if rats[0].Amount.Cmp(big.NewRat(-123456, 10000)) != 0 {
+		t.Errorf("unexpected rat: %v", rats[0].Amount)
+	}
+
+	// -12.3456 has fractional digits an integer cannot hold
+	var ints []struct {
+		Amount big.Int `json:"amount"`
+	}
+	if err := UnmarshalRecord(record, &ints); err == nil {
+		t.Errorf("want error for fractional decimal into big.Int")
+	}
+
+	var floats []struct {
+		Amount float64 `json:"amount"`
+	}
+	if _, ok := UnmarshalRecord(record, &floats).(*UnmarshalTypeError); !ok {
+		t.Errorf("want *UnmarshalTypeError for decimal into float64")
+	}
+}
+
+func TestMarshalRecordDecimal(t *testing.T) {
+	schema, _ := DeriveArrowSchema(invoice{}, nil)
+	amount, _ := ParseDecimal("-12.34")
+	fee, _ := ParseDecimal("0.5")
+	want := invoice{Amount: amount, Rate: big.NewRat(1, 8), Fees: []Decimal{fee}}
+	want.Total.SetString("123456789012345678901234567890", 10)
+
+	record, err := MarshalRecord([]invoice{want}, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	var rows []invoice
+	if err := UnmarshalRecord(record, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	got := rows[0]
+	if got.Amount.String() != "-12.3400" || got.Total.Cmp(&want.Total) != 0 ||
+		got.Rate.Cmp(want.Rate) != 0 || got.Fees[0].String() != "0.50" {
+		t.Errorf("unexpected round trip: %+v", got)
+	}
+
+	// 0.125 has more fractional digits than the fees column holds
+	fine, _ := ParseDecimal("0.125")
+	_, err = MarshalRecord([]invoice{{Fees: []Decimal{fine}}}, schema)
+	if e, ok := err.(*MarshalTypeError); !ok || e.Column != "fees[]" {
+		t.Errorf("want *MarshalTypeError for fees[], got=%v", err)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	d, err := ParseDecimal("-12.340")
+	if err != nil {
+		t.Errorf("ParseDecimal: %v", err)
+		return
+	}
+	if d.Scale() != 3 || d.Unscaled().Int64() != -12340 || d.String() != "-12.340" {
+		t.Errorf("unexpected decimal: %v", d)
+	}
+	if n, err := d.Decimal128(6, 4); err != nil || n != decimal128.FromI64(-123400) {
+		t.Errorf("unexpected Decimal128: %v, %v", n, err)
+	}
+	if _, err := d.Decimal128(4, 3); err == nil {
+		t.Errorf("want overflow error")
+	}
+	if _, err := d.Decimal128(10, 1); err == nil {
+		t.Errorf("want data loss error")
+	}
+	for _, s := range []string{"", ".", "1.2.3", "--1", "1e5"} {
+		if _, err := ParseDecimal(s); err == nil {
+			t.Errorf("want error for %q", s)
+		}
+	}
+	if (Decimal{}).Rat().Sign() != 0 {
+		t.Errorf("zero decimal is not 0")
+	}
+}
+
+// testCents is a number of cents encoding as a decimal of scale 2.
+type testCents int64
+
+func (c testCents) MarshalDecimal() (*big.Int, int32, error) {
+	if c < 0 {
+		return nil, 0, fmt.Errorf("negative amount")
+	}
+	return big.NewInt(int64(c)), 2, nil
+}
+
+func TestMarshalRecordDecimalMarshaler(t *testing.T) {
+	type price struct {
+		Amount testCents `json:"amount"`
+	}
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "amount", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}, Nullable: true},
+	}, nil)
+	record, err := MarshalRecord([]price{{Amount: 1234}}, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	if got := record.Column(0).(*array.Decimal128).Value(0); got.BigInt().Int64() != 1234 {
+		t.Errorf("want 12.34, got unscaled %v", got.BigInt())
+	}
+	if _, err := MarshalRecord([]price{{Amount: 1}, {Amount: -1}}, schema); err == nil || !strings.Contains(err.Error(), "negative amount") {
+		t.Errorf("want MarshalDecimal error, got=%v", err)
+	}
+
+	// a field whose type never fits its column fails before any row
+	type named struct {
+		Amount []string `json:"amount"`
+	}
+	_, err = MarshalRecord([]named{{}}, schema)
+	e, ok := err.(*MarshalTypeError)
+	if !ok || e.Column != "amount" || e.Row != -1 || e.Type != reflect.TypeOf([]string{}) || !arrow.TypeEqual(e.ArrowType, schema.Field(0).Type) {
+		t.Errorf("want *MarshalTypeError for amount, got=%#v", err)
+	}
+}
+
+func TestUnmarshalRecordBigFloat(t *testing.T) {
+	dt := &arrow.Decimal128Type{Precision: 38, Scale: 10}
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema([]arrow.Field{
+		{Name: "value", Type: dt, Nullable: true},
+	}, nil))
+	defer builder.Release()
+	unscaled, _ := new(big.Int).SetString("12345678901234567890123456789012345678", 10)
+	builder.Field(0).(*array.Decimal128Builder).Append(decimal128.FromBigInt(unscaled))
+	record := builder.NewRecord()
+	defer record.Release()
+
+	type measure struct {
+		Value *big.Float `json:"value"`
+	}
+	const want = "1234567890123456789012345678.9012345678"
+	var rows []measure
+	if err := UnmarshalRecord(record, &rows); err != nil || rows[0].Value.Text('f', 10) != want {
+		t.Errorf("unexpected value: %v, %v", rows, err)
+	}
+
+	// values kept from a float64 keep every digit as well
+	rows = []measure{{Value: big.NewFloat(1)}}
+	err := UnmarshalRecordWithOptions(record, &rows, DecodeSliceMode(ReuseSliceMode), DecodeNullPolicy(KeepNullPolicy))
+	if err != nil || rows[0].Value.Text('f', 10) != want {
+		t.Errorf("unexpected reused value: %v, %v", rows[0].Value, err)
+	}
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+// UUIDType is the arrow.uuid extension type of UUID columns, stored as
+// FixedSizeBinary(16). DeriveArrowSchema maps [16]byte and array types named
+// UUID onto it.
+type UUIDType struct {
+	arrow.ExtensionBase
+}
+
+// NewUUIDType returns the UUID extension type.
+func NewUUIDType() *UUIDType {
+	return &UUIDType{ExtensionBase: arrow.ExtensionBase{Storage: &arrow.FixedSizeBinaryType{ByteWidth: 16}}}
+}
+
+// ArrayType implements arrow.ExtensionType.
+func (*UUIDType) ArrayType() reflect.Type { return reflect.TypeOf(UUIDArray{}) }
+
+// ExtensionName implements arrow.ExtensionType.
+func (*UUIDType) ExtensionName() string { return "arrow.uuid" }
+
+// String implements arrow.DataType.
+func (*UUIDType) String() string { return "extension<arrow.uuid>" }
+
+// Serialize implements arrow.ExtensionType, the type having no parameter.
+func (*UUIDType) Serialize() string { return "" }
+
+// Deserialize implements arrow.ExtensionType.
+func (*UUIDType) Deserialize(storageType arrow.DataType, data string) (arrow.ExtensionType, error) {
+	if !arrow.TypeEqual(storageType, &arrow.FixedSizeBinaryType{ByteWidth: 16}) {
+		return nil, fmt.Errorf("invalid storage type for arrow.uuid: %s", storageType)
+	}
+	return NewUUIDType(), nil
+}
+
+// ExtensionEquals implements arrow.ExtensionType.
+func (t *UUIDType) ExtensionEquals(other arrow.ExtensionType) bool {
+	return t.ExtensionName() == other.ExtensionName()
+}
+
+// UUIDArray is the array of UUID columns.
+type UUIDArray struct {
+	array.ExtensionArrayBase
+}
+
+func init() {
+	// a type of the same name registered elsewhere reads IPC streams
+	// equally well, the decoder only reading the storage of extension arrays
+	_ = arrow.RegisterExtensionType(NewUUIDType())
+}
+
+// Extension describes a domain-specific column type registered with
+// RegisterExtension.
+type Extension struct {
+	// Name is the extension name, annotating columns as
+	// ARROW:extension:name.
+	Name string
+	// Metadata holds the parameters of the type, annotating columns as
+	// ARROW:extension:metadata.
+	Metadata string
+	// GoType is the type of the values of the columns.
+	GoType reflect.Type
+	// Storage is the arrow type the values are stored as.
+	Storage arrow.DataType
+	// Serialize appends v, a GoType value, to b, a builder of Storage.
+	Serialize func(b array.Builder, v any) error
+	// Deserialize sets v, a pointer to a GoType value, to the i-th value of
+	// arr, an array of Storage. The value is never null.
+	Deserialize func(arr arrow.Array, i int, v any) error
+}
+
+// RegisterExtension registers ext, for DeriveArrowSchema to map its Go type
+// onto the extension type, and for MarshalRecord and UnmarshalRecord to
+// convert its values through Serialize and Deserialize. Columns of the
+// extension type, or of its storage type, decode into the Go type. The
+// extension type is also registered with arrow, so that schemas and records
+// read from IPC streams keep it.
+//
+// Extensions are registered before the Go type is first encoded or decoded,
+// typically in an init function. When registration fails, nothing is
+// registered.
+func RegisterExtension(ext Extension) error {
+	if ext.Name == "" || ext.GoType == nil || ext.Storage == nil || ext.Serialize == nil || ext.Deserialize == nil {
+		return fmt.Errorf("extension %q: name, Go type, storage, Serialize and Deserialize are required", ext.Name)
+	}
+	typ := &extensionType{
+		ExtensionBase: arrow.ExtensionBase{Storage: ext.Storage},
+		name:          ext.Name,
+		metadata:      ext.Metadata,
+	}
+	registered := &decode.Extension{
+		GoType:      ext.GoType,
+		Type:        typ,
+		Serialize:   ext.Serialize,
+		Deserialize: ext.Deserialize,
+	}
+	if err := decode.RegisterExtension(registered); err != nil {
+		return err
+	}
+	if err := arrow.RegisterExtensionType(typ); err != nil {
+		// leave nothing registered, for the extension to be registered again
+		decode.UnregisterExtension(registered)
+		return err
+	}
+	return nil
+}
+
+// extensionType is the arrow extension type of registered extensions.
+type extensionType struct {
+	arrow.ExtensionBase
+	name     string
+	metadata string
+}
+
+// ArrayType implements arrow.ExtensionType.
+func (*extensionType) ArrayType() reflect.Type { return reflect.TypeOf(extensionArray{}) }
+
+// ExtensionName implements arrow.ExtensionType.
+func (t *extensionType) ExtensionName() string { return t.name }
+
+// String implements arrow.DataType.
+func (t *extensionType) String() string { return "extension<" + t.name + ">" }
+
+// Serialize implements arrow.ExtensionType.
+func (t *extensionType) Serialize() string { return t.metadata }
+
+// Deserialize implements arrow.ExtensionType.
+func (t *extensionType) Deserialize(storageType arrow.DataType, data string) (arrow.ExtensionType, error) {
+	if !arrow.TypeEqual(storageType, t.Storage) {
+		return nil, fmt.Errorf("invalid storage type for %s: %s", t.name, storageType)
+	}
+	return &extensionType{
+		ExtensionBase: arrow.ExtensionBase{Storage: storageType},
+		name:          t.name,
+		metadata:      data,
+	}, nil
+}
+
+// ExtensionEquals implements arrow.ExtensionType.
+func (t *extensionType) ExtensionEquals(other arrow.ExtensionType) bool {
+	return t.name == other.ExtensionName() && t.metadata == other.Serialize()
+}
+
+// extensionArray is the array of registered extension types.
+type extensionArray struct {
+	array.ExtensionArrayBase
+}
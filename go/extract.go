@@ -0,0 +1,25 @@
+package client
+
+import (
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+// Extract evaluates the JSONPath path against each row of record and decodes
+// the values matched into v, such as
+//
+//	var statuses []int
+//	err := client.Extract(record, "$.spans[*].attributes.http.status", &statuses)
+//
+// The root $ is a row, whose fields are the columns. Paths select struct
+// fields and map entries by name, list elements by index, all of them with
+// [*], and fields at any depth with ..name. They are walked over the arrow
+// arrays, so that only the values matched are decoded, as UnmarshalRecord
+// decodes columns. A slice v receives every value matched, in row order,
+// and any other v the first one. A list matched is a single value: $.tags
+// extracts the tags of each row into a *[][]string, and $.tags[*] all of
+// them into a *[]string. Extracting $.tags into a *[]string fails.
+func Extract(record arrow.Record, path string, v any, optFuncs ...DecodeOptionFunc) error {
+	return decode.Extract(record, path, v, newDecodeOption(optFuncs))
+}
@@ -0,0 +1,284 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+// SchemaConflict describes a column whose sampled values have JSON types that
+// do not unify, which InferSchemaFromJSON makes a string column.
+type SchemaConflict struct {
+	Column string   // path of the column, "." joining struct fields and "[]" marking list elements
+	Types  []string // JSON types of the non-null values, in order of appearance
+}
+
+// InferSchemaFromJSON returns the schema of the JSON objects read from r, a
+// stream of objects or of arrays of objects, and the columns whose values
+// conflict. The types of the values are unified across documents: integers
+// widen to floats, nulls take the type of the other values of their column
+// and the objects of arrays merge their fields, in order of appearance.
+// Strings are times when every value parses with the layout of the
+// DateFormat of their column, RFC 3339 by default. Columns with only nulls
+// have the null type.
+func InferSchemaFromJSON(r io.Reader, optFuncs ...InferOptionFunc) (*arrow.Schema, []SchemaConflict, error) {
+	opt := newInferOption(optFuncs)
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	in := &inferrer{dec: dec, format: opt.DateFormat}
+	root := &inferredType{}
+	docs := 0
+	sampled := func() bool {
+		return opt.SampleSize > 0 && docs >= opt.SampleSize
+	}
+	for !sampled() {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		inArray := tok == json.Delim('[')
+		for !inArray || (dec.More() && !sampled()) {
+			if inArray {
+				if tok, err = dec.Token(); err != nil {
+					return nil, nil, err
+				}
+			}
+			if tok != json.Delim('{') {
+				return nil, nil, fmt.Errorf("document %d is not a JSON object", docs)
+			}
+			if err := in.observe(root, tok, ""); err != nil {
+				return nil, nil, err
+			}
+			docs++
+			if !inArray {
+				break
+			}
+		}
+		if inArray && !sampled() {
+			if _, err := dec.Token(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if docs == 0 {
+		return nil, nil, fmt.Errorf("no JSON document to infer a schema from")
+	}
+
+	fields := in.arrowFields(root, "")
+	return arrow.NewSchema(fields, nil), in.conflicts, nil
+}
+
+type jsonKind int
+
+const (
+	jsonNull jsonKind = iota
+	jsonBool
+	jsonInt
+	jsonFloat
+	jsonTime
+	jsonString
+	jsonList
+	jsonObject
+)
+
+// inferredType is the unified type of the values of a column.
+type inferredType struct {
+	kind     jsonKind
+	conflict bool
+	types    []string                 // JSON types of the non-null values, in order of appearance
+	elem     *inferredType            // type of list elements
+	names    []string                 // object fields, in order of appearance
+	fields   map[string]*inferredType // type of object fields
+}
+
+// merge unifies t with a value of kind k and JSON type jsonType.
+func (t *inferredType) merge(k jsonKind, jsonType string) {
+	if k != jsonNull && !containsString(t.types, jsonType) {
+		t.types = append(t.types, jsonType)
+	}
+	switch {
+	case t.conflict || k == jsonNull || k == t.kind:
+	case t.kind == jsonNull:
+		t.kind = k
+		switch k {
+		case jsonList:
+			t.elem = &inferredType{}
+		case jsonObject:
+			t.fields = map[string]*inferredType{}
+		}
+	case isNumberKind(t.kind) && isNumberKind(k):
+		t.kind = jsonFloat
+	case isStringKind(t.kind) && isStringKind(k):
+		// a string that is not a time makes the column a string one
+		t.kind = jsonString
+	default:
+		t.kind, t.conflict = jsonString, true
+		t.elem, t.names, t.fields = nil, nil, nil
+	}
+}
+
+// field returns the type of the object field name, a discarded one once t
+// conflicts.
+func (t *inferredType) field(name string) *inferredType {
+	if t.kind != jsonObject {
+		return &inferredType{}
+	}
+	f, ok := t.fields[name]
+	if !ok {
+		f = &inferredType{}
+		t.names = append(t.names, name)
+		t.fields[name] = f
+	}
+	return f
+}
+
+// inferrer reads JSON documents and unifies the types of their values.
+type inferrer struct {
+	dec       *json.Decoder
+	format    map[string]DateFormat
+	conflicts []SchemaConflict
+}
+
+// observe merges the type of the JSON value starting with tok into t. name is
+// the column name, which selects the DateFormat of times.
+func (in *inferrer) observe(t *inferredType, tok json.Token, name string) error {
+	switch v := tok.(type) {
+	case nil:
+		t.merge(jsonNull, "null")
+	case bool:
+		t.merge(jsonBool, "boolean")
+	case json.Number:
+		t.merge(in.numberKind(v, name), "number")
+	case string:
+		t.merge(in.stringKind(v, name), "string")
+	case json.Delim:
+		if v == '[' {
+			t.merge(jsonList, "array")
+			elem := t.elem
+			if elem == nil {
+				elem = &inferredType{}
+			}
+			for in.dec.More() {
+				tok, err := in.dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := in.observe(elem, tok, name); err != nil {
+					return err
+				}
+			}
+		} else {
+			t.merge(jsonObject, "object")
+			for in.dec.More() {
+				key, err := in.dec.Token()
+				if err != nil {
+					return err
+				}
+				tok, err := in.dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := in.observe(t.field(key.(string)), tok, key.(string)); err != nil {
+					return err
+				}
+			}
+		}
+		// closing delimiter
+		_, err := in.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// numberKind returns the kind of v, a time in columns with an epoch layout.
+func (in *inferrer) numberKind(v json.Number, name string) jsonKind {
+	if _, err := strconv.ParseInt(v.String(), 10, 64); err != nil {
+		return jsonFloat
+	}
+	if tf, ok := in.format[name]; ok && decode.NewTimeLayout(tf.Layout).IsEpoch() {
+		return jsonTime
+	}
+	return jsonInt
+}
+
+// stringKind returns the kind of s, a time when it parses with the layout of
+// the column.
+func (in *inferrer) stringKind(s, name string) jsonKind {
+	if _, err := decode.NewTimeLayout(in.dateFormat(name).Layout).Parse(s); err == nil {
+		return jsonTime
+	}
+	return jsonString
+}
+
+func (in *inferrer) dateFormat(name string) DateFormat {
+	if tf, ok := in.format[name]; ok {
+		return tf
+	}
+	return defaultDateFormat
+}
+
+// arrowFields returns the columns of the object type t at column, reporting
+// the conflicting ones.
+func (in *inferrer) arrowFields(t *inferredType, column string) []arrow.Field {
+	fields := make([]arrow.Field, 0, len(t.names))
+	for _, name := range t.names {
+		arrowType, metadata := in.arrowType(t.fields[name], name, joinColumn(column, name))
+		fields = append(fields, arrow.Field{
+			Name:     name,
+			Type:     arrowType,
+			Nullable: true,
+			Metadata: metadata,
+		})
+	}
+	return fields
+}
+
+func (in *inferrer) arrowType(t *inferredType, name, column string) (arrow.DataType, arrow.Metadata) {
+	if t.conflict {
+		in.conflicts = append(in.conflicts, SchemaConflict{Column: column, Types: t.types})
+	}
+	switch t.kind {
+	case jsonNull:
+		return arrow.Null, arrow.Metadata{}
+	case jsonBool:
+		return &arrow.BooleanType{}, arrow.Metadata{}
+	case jsonInt:
+		return &arrow.Int64Type{}, arrow.Metadata{}
+	case jsonFloat:
+		return &arrow.Float64Type{}, arrow.Metadata{}
+	case jsonTime:
+		return dateFormatArrowType(in.dateFormat(name))
+	case jsonList:
+		arrowType, metadata := in.arrowType(t.elem, name, column+"[]")
+		return arrow.ListOfField(arrow.Field{Type: arrowType, Nullable: true}), metadata
+	case jsonObject:
+		return arrow.StructOf(in.arrowFields(t, column)...), arrow.Metadata{}
+	}
+	return &arrow.StringType{}, arrow.Metadata{}
+}
+
+func isNumberKind(k jsonKind) bool {
+	return k == jsonInt || k == jsonFloat
+}
+
+func isStringKind(k jsonKind) bool {
+	return k == jsonTime || k == jsonString
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
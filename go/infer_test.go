@@ -0,0 +1,89 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow"
+)
+
+func TestInferSchemaFromJSON(t *testing.T) {
+	docs := `
+{"service": "frontend", "status": 200, "latency": 12, "start": "2022-10-01T08:00:00Z", "tags": [], "attrs": null}
+{"service": "backend", "status": 500, "latency": 3.5, "start": "2022-10-01T08:00:01.5Z", "tags": ["a"], "spans": [{"id": 1}]}
+[{"service": "db", "latency": null, "day": "2022-10-01", "spans": [{"id": 2, "name": "query"}, {"name": "commit", "ok": true}]}]
+`
+	schema, conflicts, err := InferSchemaFromJSON(strings.NewReader(docs), InferDateFormat(map[string]DateFormat{
+		"day": {Is32Bits: true, Layout: "2006-01-02"},
+	}))
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("want no conflict, got=%v", conflicts)
+	}
+
+	want := []arrow.Field{
+		{Name: "service", Type: &arrow.StringType{}, Nullable: true, Metadata: arrow.Metadata{}},
+		{Name: "status", Type: &arrow.Int64Type{}, Nullable: true, Metadata: arrow.Metadata{}},
+		{Name: "latency", Type: &arrow.Float64Type{}, Nullable: true, Metadata: arrow.Metadata{}},
+		{Name: "start", Type: &arrow.TimestampType{Unit: arrow.Millisecond}, Nullable: true, Metadata: layoutMetadata("2006-01-02T15:04:05.999999999Z07:00")},
+		{Name: "tags", Type: arrow.ListOf(&arrow.StringType{}), Nullable: true, Metadata: arrow.Metadata{}},
+		{Name: "attrs", Type: arrow.Null, Nullable: true, Metadata: arrow.Metadata{}},
+		{Name: "spans", Type: arrow.ListOf(arrow.StructOf(
+			arrow.Field{Name: "id", Type: &arrow.Int64Type{}, Nullable: true, Metadata: arrow.Metadata{}},
+			arrow.Field{Name: "name", Type: &arrow.StringType{}, Nullable: true, Metadata: arrow.Metadata{}},
+			arrow.Field{Name: "ok", Type: &arrow.BooleanType{}, Nullable: true, Metadata: arrow.Metadata{}},
+		)), Nullable: true, Metadata: arrow.Metadata{}},
+		{Name: "day", Type: &arrow.Date32Type{}, Nullable: true, Metadata: layoutMetadata("2006-01-02")},
+	}
+	if got := schema.Fields(); len(got) != len(want) {
+		t.Errorf("want=%v, got=%v", want, got)
+	} else {
+		for i := range want {
+			if !got[i].Equal(want[i]) || !got[i].Metadata.Equal(want[i].Metadata) {
+				t.Errorf("want=%v, got=%v", want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestInferSchemaFromJSONConflicts(t *testing.T) {
+	docs := `
+{"id": 1, "start": "2022-10-01T08:00:00Z", "value": 1, "spans": [{"parent": 1}]}
+{"id": "b", "start": "yesterday", "value": {"x": 1}, "spans": [{"parent": [1]}]}
+{"id": 3, "value": [2]}
+`
+	schema, conflicts, err := InferSchemaFromJSON(strings.NewReader(docs))
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	for _, name := range []string{"id", "start", "value"} {
+		if f, ok := schema.FieldsByName(name); !ok || f[0].Type.ID() != arrow.STRING {
+			t.Errorf("want string column %s, got=%v", name, f)
+		}
+	}
+	want := []SchemaConflict{
+		{Column: "id", Types: []string{"number", "string"}},
+		{Column: "value", Types: []string{"number", "object", "array"}},
+		{Column: "spans[].parent", Types: []string{"number", "array"}},
+	}
+	if !reflect.DeepEqual(want, conflicts) {
+		t.Errorf("want=%v, got=%v", want, conflicts)
+	}
+
+	schema, conflicts, err = InferSchemaFromJSON(strings.NewReader(docs), InferSampleSize(1))
+	if err != nil || len(conflicts) != 0 || schema.Field(0).Type.ID() != arrow.INT64 {
+		t.Errorf("want the first document only, got=%v, %v, %v", schema, conflicts, err)
+	}
+
+	if _, _, err := InferSchemaFromJSON(strings.NewReader(`{"a": 1} 2`)); err == nil {
+		t.Errorf("want error for a document which is not an object")
+	}
+	if _, _, err := InferSchemaFromJSON(strings.NewReader(" ")); err == nil {
+		t.Errorf("want error without documents")
+	}
+}
@@ -2,10 +2,10 @@ package decode
 
 import (
 	"fmt"
+	"reflect"
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -37,20 +37,25 @@ func newArrayDecoder(dec Decoder, elemType *runtime.Type, alen int, structName,
 }
 
 func (d *arrayDecoder) DecodeArray(s arrow.Array, i int, p unsafe.Pointer) error {
+	return d.decodeArrayIn(s, i, p, ColumnTimeLocation)
+}
+
+func (d *arrayDecoder) decodeArrayIn(s arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
 	if s.IsNull(i) {
 		for idx := 0; idx < d.alen; idx++ {
 			*(*unsafe.Pointer)(unsafe.Pointer(uintptr(p) + uintptr(idx)*d.size)) = d.zeroValue
 		}
 	} else {
-		list := s.(*array.List)
-		start, end := list.ValueOffsets(i)
-		if d.alen != int(end-start) {
-			return fmt.Errorf("array length is not equal")
+		values, start, end, ok := listValueRange(s, i)
+		if !ok {
+			return errors.ErrArrowType("", s.DataType(), reflect.ArrayOf(d.alen, runtime.RType2Type(d.elemType)), -1)
+		}
+		if d.alen != end-start {
+			return errors.ErrArrowType("", s.DataType(), reflect.ArrayOf(d.alen, runtime.RType2Type(d.elemType)), -1)
 		}
-		values := list.ListValues()
 		for idx := 0; idx < d.alen; idx++ {
-			if err := d.valueDecoder.DecodeArray(values, int(start)+idx, unsafe.Pointer(uintptr(p)+uintptr(idx)*d.size)); err != nil {
-				return err
+			if err := decodeArrayWith(d.valueDecoder, values, start+idx, unsafe.Pointer(uintptr(p)+uintptr(idx)*d.size), loc); err != nil {
+				return annotateColumn(err, "[]")
 			}
 		}
 	}
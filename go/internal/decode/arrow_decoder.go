@@ -2,6 +2,8 @@ package decode
 
 import (
 	"reflect"
+	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
@@ -11,31 +13,285 @@ import (
 	"github.com/chronowave/client/go/internal/runtime"
 )
 
-func Unmarshal(record arrow.Record, v interface{}) error {
+func Unmarshal(record arrow.Record, v interface{}, opt *Option) error {
 	header := (*emptyInterface)(unsafe.Pointer(&v))
 
 	if err := validateType(header.typ, uintptr(header.ptr)); err != nil {
 		return err
 	}
 
-	dec, err := CompileToGetDecoder(header.typ)
+	plan, err := compileToGetRecordPlan(header.typ, record.Schema(), opt)
 	if err != nil {
 		return err
 	}
-
-	sliceDec, ok := dec.(*sliceDecoder)
-	if !ok {
-		return &errors.InvalidUnmarshalError{Type: runtime.RType2Type(header.typ)}
-	}
-
-	arr := array.RecordToStructArray(record)
-	defer arr.Release()
-	return sliceDec.DecodeStructArray(arr, header.ptr)
+	return plan.decode(record, header.ptr, opt)
 }
 
 func validateType(typ *runtime.Type, p uintptr) error {
 	if typ == nil || typ.Kind() != reflect.Ptr || p == 0 {
-		return &errors.InvalidUnmarshalError{Type: runtime.RType2Type(typ)}
+		return errors.ErrInvalidRecordUnmarshal(runtime.RType2Type(typ))
 	}
 	return nil
 }
+
+// listValueRange returns the child values of the i-th list in arr together
+// with the [start, end) range they occupy, for every list layout:
+// List, LargeList and FixedSizeList.
+func listValueRange(arr arrow.Array, i int) (arrow.Array, int, int, bool) {
+	switch list := arr.(type) {
+	case array.ListLike:
+		start, end := list.ValueOffsets(i)
+		return list.ListValues(), int(start), int(end), true
+	case *array.FixedSizeList:
+		n := int(list.DataType().(*arrow.FixedSizeListType).Len())
+		start := (list.Data().Offset() + i) * n
+		return list.ListValues(), start, start + n, true
+	}
+	return nil, 0, 0, false
+}
+
+// stringValue returns the i-th value of any string or binary layout as string.
+// The returned string aliases the arrow buffer.
+func stringValue(arr arrow.Array, i int) (string, bool) {
+	switch a := arr.(type) {
+	case *array.String:
+		return a.Value(i), true
+	case *array.LargeString:
+		return a.Value(i), true
+	case *array.Binary:
+		return a.ValueString(i), true
+	case *array.LargeBinary:
+		return a.ValueString(i), true
+	case *array.FixedSizeBinary:
+		v := a.Value(i)
+		return *(*string)(unsafe.Pointer(&v)), true
+	}
+	return "", false
+}
+
+// cloneString returns a copy of s which does not alias the arrow buffer.
+func cloneString(s string) string {
+	if s == "" {
+		return ""
+	}
+	b := make([]byte, len(s))
+	copy(b, s)
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// bytesValue returns the i-th value of any binary or string layout as []byte.
+// Binary values alias the arrow buffer, string values are copied.
+func bytesValue(arr arrow.Array, i int) ([]byte, bool) {
+	switch a := arr.(type) {
+	case *array.Binary:
+		return a.Value(i), true
+	case *array.LargeBinary:
+		return a.Value(i), true
+	case *array.FixedSizeBinary:
+		return a.Value(i), true
+	case *array.String, *array.LargeString:
+		s, _ := stringValue(arr, i)
+		return []byte(s), true
+	}
+	return nil, false
+}
+
+// int64Value returns the i-th value of any integer layout widened to int64.
+func int64Value(arr arrow.Array, i int) (int64, bool) {
+	switch a := arr.(type) {
+	case *array.Int8:
+		return int64(a.Value(i)), true
+	case *array.Int16:
+		return int64(a.Value(i)), true
+	case *array.Int32:
+		return int64(a.Value(i)), true
+	case *array.Int64:
+		return a.Value(i), true
+	case *array.Uint8:
+		return int64(a.Value(i)), true
+	case *array.Uint16:
+		return int64(a.Value(i)), true
+	case *array.Uint32:
+		return int64(a.Value(i)), true
+	case *array.Uint64:
+		return int64(a.Value(i)), true
+	}
+	return 0, false
+}
+
+// uint64Value returns the i-th value of any integer layout as uint64.
+// Signed values are sign-extended, so the unsigned Go integers
+// DeriveArrowSchema stores in signed columns of the same width decode back
+// once truncated to that width.
+func uint64Value(arr arrow.Array, i int) (uint64, bool) {
+	switch a := arr.(type) {
+	case *array.Int8:
+		return uint64(a.Value(i)), true
+	case *array.Int16:
+		return uint64(a.Value(i)), true
+	case *array.Int32:
+		return uint64(a.Value(i)), true
+	case *array.Int64:
+		return uint64(a.Value(i)), true
+	case *array.Uint8:
+		return uint64(a.Value(i)), true
+	case *array.Uint16:
+		return uint64(a.Value(i)), true
+	case *array.Uint32:
+		return uint64(a.Value(i)), true
+	case *array.Uint64:
+		return a.Value(i), true
+	}
+	return 0, false
+}
+
+// timeValue returns the i-th value of a timestamp, date or time of day layout
+// as time.Time. Timestamps are in the location selected by loc, the other
+// layouts in UTC, times of day falling on January 1, 1970. It fails for
+// timestamps of an invalid time zone, whatever loc selects.
+func timeValue(arr arrow.Array, i int, loc TimeLocation) (time.Time, bool, error) {
+	switch a := arr.(type) {
+	case *array.Timestamp:
+		typ := a.DataType().(*arrow.TimestampType)
+		l, err := timeLocation(loc, typ.TimeZone)
+		if err != nil {
+			return time.Time{}, true, errTimeZone("", typ.TimeZone, err)
+		}
+		return a.Value(i).ToTime(typ.Unit).In(l), true, nil
+	case *array.Date32:
+		return a.Value(i).ToTime(), true, nil
+	case *array.Date64:
+		return a.Value(i).ToTime(), true, nil
+	case *array.Time32:
+		return a.Value(i).ToTime(a.DataType().(*arrow.Time32Type).Unit), true, nil
+	case *array.Time64:
+		return a.Value(i).ToTime(a.DataType().(*arrow.Time64Type).Unit), true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// locationArrayDecoder is implemented by the decoders of values which may
+// hold timestamps decoded without a plan, such as maps and interfaces, to
+// decode them in the location selected by loc.
+type locationArrayDecoder interface {
+	decodeArrayIn(arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error
+}
+
+// decodeArrayWith decodes the i-th value of arr into p with dec, timestamps
+// in the location selected by loc.
+func decodeArrayWith(dec Decoder, arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
+	if d, ok := dec.(locationArrayDecoder); ok {
+		return d.decodeArrayIn(arr, i, p, loc)
+	}
+	return dec.DecodeArray(arr, i, p)
+}
+
+var locationCache sync.Map // map[string]*time.Location
+
+// loadLocation is time.LoadLocation caching its results, since loading a
+// location reads the time zone database.
+func loadLocation(name string) (*time.Location, error) {
+	if l, ok := locationCache.Load(name); ok {
+		return l.(*time.Location), nil
+	}
+	l, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, l)
+	return l, nil
+}
+
+// timeLocation returns the location selected by loc for a timestamp column
+// of the time zone tz. An invalid tz is an error whatever loc selects.
+func timeLocation(loc TimeLocation, tz string) (*time.Location, error) {
+	l, err := loadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	switch loc {
+	case UTCTimeLocation:
+		return time.UTC, nil
+	case LocalTimeLocation:
+		return time.Local, nil
+	}
+	return l, nil
+}
+
+// durationValue returns the i-th value of a duration or time of day layout
+// as time.Duration, times of day being the time elapsed since midnight.
+func durationValue(arr arrow.Array, i int) (time.Duration, bool) {
+	switch a := arr.(type) {
+	case *array.Duration:
+		return time.Duration(a.Value(i)) * a.DataType().(*arrow.DurationType).Unit.Multiplier(), true
+	case *array.Time32:
+		return time.Duration(a.Value(i)) * a.DataType().(*arrow.Time32Type).Unit.Multiplier(), true
+	case *array.Time64:
+		return time.Duration(a.Value(i)) * a.DataType().(*arrow.Time64Type).Unit.Multiplier(), true
+	}
+	return 0, false
+}
+
+// durationUnit returns the time unit of a duration or time of day type.
+func durationUnit(dt arrow.DataType) (time.Duration, bool) {
+	switch t := dt.(type) {
+	case *arrow.DurationType:
+		return t.Unit.Multiplier(), true
+	case *arrow.Time32Type:
+		return t.Unit.Multiplier(), true
+	case *arrow.Time64Type:
+		return t.Unit.Multiplier(), true
+	}
+	return 0, false
+}
+
+// float64Value returns the i-th value of any floating point or integer
+// layout as float64.
+func float64Value(arr arrow.Array, i int) (float64, bool) {
+	switch a := arr.(type) {
+	case *array.Float32:
+		return float64(a.Value(i)), true
+	case *array.Float64:
+		return a.Value(i), true
+	case *array.Uint64:
+		return float64(a.Value(i)), true
+	}
+	if v, ok := int64Value(arr, i); ok {
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// annotateRow records the record row on an arrow type error raised while decoding it.
+func annotateRow(err error, row int) error {
+	if e, ok := err.(*errors.UnmarshalTypeError); ok && e.ArrowType != nil && e.Row < 0 {
+		e.Row = row
+	}
+	return err
+}
+
+// annotateColumn prefixes the column path of an arrow type error raised while
+// decoding a nested value with the name of the enclosing column.
+func annotateColumn(err error, name string) error {
+	if e, ok := err.(*errors.UnmarshalTypeError); ok && e.ArrowType != nil {
+		switch {
+		case e.Column == "":
+			e.Column = name
+		case e.Column[0] == '[':
+			e.Column = name + e.Column
+		default:
+			e.Column = name + "." + e.Column
+		}
+	}
+	return err
+}
+
+// annotateElement prefixes the column path of an arrow type error raised while
+// decoding a list element. The row index referred to the child array, so it is
+// reset for the enclosing decoder to set.
+func annotateElement(err error) error {
+	if e, ok := err.(*errors.UnmarshalTypeError); ok && e.ArrowType != nil {
+		e.Row = -1
+	}
+	return annotateColumn(err, "[]")
+}
@@ -2,6 +2,7 @@ package decode
 
 import (
 	"fmt"
+	"reflect"
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
@@ -20,9 +21,14 @@ func newBoolDecoder(structName, fieldName string) *boolDecoder {
 }
 
 func (d *boolDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
-	if arr.IsValid(i) {
-		**(**bool)(unsafe.Pointer(&p)) = arr.(*array.Boolean).Value(i)
+	if arr.IsNull(i) {
+		return nil
 	}
+	b, ok := arr.(*array.Boolean)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), reflect.TypeOf(true), -1)
+	}
+	**(**bool)(unsafe.Pointer(&p)) = b.Value(i)
 	return nil
 }
 
@@ -3,9 +3,11 @@ package decode
 import (
 	"encoding/base64"
 	"fmt"
+	"reflect"
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -42,28 +44,56 @@ func newBytesDecoder(typ *runtime.Type, structName string, fieldName string) *by
 	}
 }
 
-func (d *bytesDecoder) DecodeArray(s arrow.Array, i int, p unsafe.Pointer) error {
-	/*
-		bytes, err := d.decodeStreamBinary(s, depth, p)
-		if err != nil {
-			return err
-		}
-		if bytes == nil {
-			s.reset()
-			return nil
-		}
-		decodedLen := base64.StdEncoding.DecodedLen(len(bytes))
-		buf := make([]byte, decodedLen)
-		n, err := base64.StdEncoding.Decode(buf, bytes)
-		if err != nil {
-			return err
-		}
-		*(*[]byte)(p) = buf[:n]
-		s.reset()
-	*/
+func (d *bytesDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		*(*[]byte)(p) = nil
+		return nil
+	}
+	if _, _, _, ok := listValueRange(arr, i); ok {
+		return d.sliceDecoder.DecodeArray(arr, i, p)
+	}
+	src, ok := bytesValue(arr, i)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), reflect.SliceOf(runtime.RType2Type(d.typ)), -1)
+	}
+	buf := make([]byte, len(src))
+	copy(buf, src)
+	*(*[]byte)(p) = buf
+	return nil
+}
+
+// aliasBytesArrowDecoder decodes string and binary columns into byte slices
+// aliasing the record buffers, for ZeroCopyOption. Their capacity ends with
+// the value, so appending to them never writes into the record.
+type aliasBytesArrowDecoder struct {
+	typ *runtime.Type
+}
+
+func (d *aliasBytesArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		*(*[]byte)(p) = nil
+		return nil
+	}
+	switch arr.(type) {
+	case *array.String, *array.LargeString:
+		// the bytes of the string rather than a copy of them
+		s, _ := stringValue(arr, i)
+		h := (*sliceHeader)(unsafe.Pointer(&s))
+		*(*sliceHeader)(p) = sliceHeader{data: h.data, len: len(s), cap: len(s)}
+		return nil
+	}
+	src, ok := bytesValue(arr, i)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.typ), -1)
+	}
+	*(*[]byte)(p) = src[:len(src):len(src)]
 	return nil
 }
 
+func (d *aliasBytesArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
 func (d *bytesDecoder) Decode(ctx *RuntimeContext, cursor, depth int64, p unsafe.Pointer) (int64, error) {
 	bytes, c, err := d.decodeBinary(ctx, cursor, depth, p)
 	if err != nil {
@@ -0,0 +1,140 @@
+package decode
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+// UnmarshalColumns decodes record into a struct whose fields are slices,
+// filling each field with the whole column of the same name.
+func UnmarshalColumns(record arrow.Record, v interface{}, opt *Option) error {
+	header := (*emptyInterface)(unsafe.Pointer(&v))
+
+	if typ := header.typ; typ == nil || typ.Kind() != reflect.Ptr || header.ptr == nil {
+		return errors.ErrInvalidColumnsUnmarshal(runtime.RType2Type(typ))
+	}
+
+	plan, err := compileToGetColumnsPlan(header.typ, record.Schema(), opt)
+	if err != nil {
+		return err
+	}
+	return plan.decode(record, header.ptr, opt)
+}
+
+type columnArrowField struct {
+	index    int
+	offset   uintptr
+	name     string
+	embedded []*anonymousFieldDecoder
+	rows     sliceRows
+	elem     arrowDecoder
+}
+
+// columnsPlan decodes the columns of a record into the slice fields of a struct.
+type columnsPlan struct {
+	fields []columnArrowField
+}
+
+func compileToGetColumnsPlan(typ *runtime.Type, schema *arrow.Schema, opt *Option) (*columnsPlan, error) {
+	key := newPlanKey(typ, schema, true, opt)
+	planMap := loadPlanMap()
+	if plan, exists := planMap[key]; exists {
+		return plan.columns, nil
+	}
+
+	plan, err := compileColumnsPlan(typ, schema, opt)
+	if err != nil {
+		return nil, err
+	}
+	if key.schema != "" {
+		storePlan(key, &recordPlan{columns: plan}, planMap)
+	}
+	return plan, nil
+}
+
+func compileColumnsPlan(typ *runtime.Type, schema *arrow.Schema, opt *Option) (*columnsPlan, error) {
+	dec, err := CompileToGetDecoder(typ)
+	if err != nil {
+		return nil, err
+	}
+	structDec, ok := dec.(*structDecoder)
+	if !ok {
+		return nil, errors.ErrInvalidColumnsUnmarshal(runtime.RType2Type(typ))
+	}
+
+	structType := typ.Elem()
+	plan := &columnsPlan{}
+	matched := make(map[*structFieldSet]bool, len(schema.Fields()))
+	for i, field := range matchColumns(structDec, schema.Fields()) {
+		f := schema.Field(i)
+		if field == nil {
+			if opt.Flags&DisallowUnknownColumnsOption != 0 {
+				return nil, errors.ErrUnknownColumn(f.Name, structType.Name())
+			}
+			continue
+		}
+		matched[field] = true
+		column := columnArrowField{index: i, offset: field.offset, name: f.Name}
+		fieldDec := field.dec
+		for {
+			anonymous, ok := fieldDec.(*anonymousFieldDecoder)
+			if !ok {
+				break
+			}
+			column.embedded = append(column.embedded, anonymous)
+			fieldDec = anonymous.dec
+		}
+		if bytesDec, ok := fieldDec.(*bytesDecoder); ok {
+			fieldDec = bytesDec.sliceDecoder
+		}
+		sliceDec, ok := fieldDec.(*sliceDecoder)
+		if !ok {
+			err := errArrowType(f.Name, f.Type, field.typ)
+			err.Struct = structType.Name()
+			err.Field = field.key
+			return nil, err
+		}
+		elem, err := compileArrowDecoder(sliceDec.elemType, sliceDec.valueDecoder, f.Type, "", FieldLayout(f, ""), opt)
+		if err != nil {
+			if e, ok := err.(*errors.UnmarshalTypeError); ok && e.Struct == "" {
+				e.Struct = structType.Name()
+				e.Field = field.key
+			}
+			return nil, annotateColumn(err, f.Name)
+		}
+		column.rows = newSliceRows(sliceDec.elemType, sliceDec.size)
+		column.elem = withNullPolicy(elem, sliceDec.elemType, structType.Name(), field.key, opt)
+		plan.fields = append(plan.fields, column)
+	}
+	if err := checkMissingColumns(structDec, matched, structType, "", opt); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (p *columnsPlan) decode(record arrow.Record, ptr unsafe.Pointer, opt *Option) error {
+	n := int(record.NumRows())
+	for _, f := range p.fields {
+		fp := unsafe.Pointer(uintptr(ptr) + f.offset)
+		for _, anonymous := range f.embedded {
+			if *(*unsafe.Pointer)(fp) == nil {
+				*(*unsafe.Pointer)(fp) = unsafe_New(anonymous.structType)
+			}
+			fp = unsafe.Pointer(uintptr(*(*unsafe.Pointer)(fp)) + anonymous.offset)
+		}
+		data, _ := f.rows.prepare((*sliceHeader)(fp), n, opt.SliceMode, opt.NullPolicy == KeepNullPolicy)
+		column := record.Column(f.index)
+		err := decodeChunks(n, opt.Workers, opt.ChunkRows, func(from, to int) error {
+			return f.elem.decodeRange(column, from, to, f.rows.elem(data, from), f.rows.size)
+		})
+		if err != nil {
+			return annotateColumn(err, f.name)
+		}
+	}
+	return nil
+}
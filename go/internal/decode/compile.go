@@ -16,6 +16,7 @@ var (
 	jsonNumberType   = reflect.TypeOf(json.Number(""))
 	typeAddr         *runtime.TypeAddr
 	cachedDecoderMap unsafe.Pointer // map[uintptr]decoder
+	cachedPlanMap    unsafe.Pointer // map[planKey]*recordPlan
 	cachedDecoder    []Decoder
 )
 
@@ -43,6 +44,32 @@ func storeDecoder(typ uintptr, dec Decoder, m map[uintptr]Decoder) {
 	atomic.StorePointer(&cachedDecoderMap, *(*unsafe.Pointer)(unsafe.Pointer(&newDecoderMap)))
 }
 
+func loadPlanMap() map[planKey]*recordPlan {
+	p := atomic.LoadPointer(&cachedPlanMap)
+	return *(*map[planKey]*recordPlan)(unsafe.Pointer(&p))
+}
+
+// maxCachedPlans bounds the plans cached for the pairs of Go type and schema
+// decoded, which a long-running client seeing many schemas would otherwise
+// accumulate forever.
+const maxCachedPlans = 256
+
+// storePlan caches plan under key. A full cache is dropped, the plans still
+// in use being compiled again on their next record.
+func storePlan(key planKey, plan *recordPlan, m map[planKey]*recordPlan) {
+	if len(m) >= maxCachedPlans {
+		m = nil
+	}
+	newPlanMap := make(map[planKey]*recordPlan, len(m)+1)
+	newPlanMap[key] = plan
+
+	for k, v := range m {
+		newPlanMap[k] = v
+	}
+
+	atomic.StorePointer(&cachedPlanMap, *(*unsafe.Pointer)(unsafe.Pointer(&newPlanMap)))
+}
+
 func compileToGetDecoderSlowPath(typeptr uintptr, typ *runtime.Type) (Decoder, error) {
 	decoderMap := loadDecoderMap()
 	if dec, exists := decoderMap[typeptr]; exists {
@@ -63,22 +90,52 @@ func compileHead(typ *runtime.Type, structTypeToDecoder map[uintptr]Decoder) (De
 		return newUnmarshalJSONDecoder(runtime.PtrTo(typ), "", ""), nil
 	case runtime.PtrTo(typ).Implements(unmarshalTextType):
 		return newUnmarshalTextDecoder(runtime.PtrTo(typ), "", ""), nil
+	case runtime.PtrTo(typ).Implements(unmarshalDecimalType):
+		return newUnmarshalDecimalDecoder(runtime.PtrTo(typ), "", ""), nil
 	}
 	return compile(typ.Elem(), "", "", structTypeToDecoder)
 }
 
 func compile(typ *runtime.Type, structName, fieldName string, structTypeToDecoder map[uintptr]Decoder) (Decoder, error) {
+	if ext := LookupExtension(runtime.RType2Type(typ)); ext != nil {
+		dec, err := compileValue(typ, structName, fieldName, structTypeToDecoder)
+		if err != nil {
+			return nil, err
+		}
+		return newExtensionDecoder(dec, ext), nil
+	}
+	if runtime.PtrTo(typ).Implements(unmarshalArrowType) {
+		dec, err := compileValue(typ, structName, fieldName, structTypeToDecoder)
+		if err != nil {
+			return nil, err
+		}
+		return newUnmarshalArrowDecoder(runtime.PtrTo(typ), dec, structName, fieldName), nil
+	}
+	return compileValue(typ, structName, fieldName, structTypeToDecoder)
+}
+
+// compileValue returns the decoder of typ ignoring ArrowUnmarshaler.
+func compileValue(typ *runtime.Type, structName, fieldName string, structTypeToDecoder map[uintptr]Decoder) (Decoder, error) {
 	switch {
 	case implementsUnmarshalJSONType(runtime.PtrTo(typ)):
 		return newUnmarshalJSONDecoder(runtime.PtrTo(typ), structName, fieldName), nil
 	case runtime.PtrTo(typ).Implements(unmarshalTextType):
 		return newUnmarshalTextDecoder(runtime.PtrTo(typ), structName, fieldName), nil
+	case runtime.PtrTo(typ).Implements(unmarshalDecimalType):
+		return newUnmarshalDecimalDecoder(runtime.PtrTo(typ), structName, fieldName), nil
 	}
 
 	switch typ.Kind() {
 	case reflect.Ptr:
 		return compilePtr(typ, structName, fieldName, structTypeToDecoder)
 	case reflect.Struct:
+		if typ == monthDayNanoIntervalType {
+			dec, err := compileStruct(typ, structName, fieldName, structTypeToDecoder)
+			if err != nil {
+				return nil, err
+			}
+			return newIntervalDecoder(dec), nil
+		}
 		return compileStruct(typ, structName, fieldName, structTypeToDecoder)
 	case reflect.Slice:
 		elem := typ.Elem()
@@ -130,10 +187,14 @@ func compile(typ *runtime.Type, structName, fieldName string, structTypeToDecode
 
 func isStringTagSupportedType(typ *runtime.Type) bool {
 	switch {
+	case runtime.PtrTo(typ).Implements(unmarshalArrowType):
+		return false
 	case implementsUnmarshalJSONType(runtime.PtrTo(typ)):
 		return false
 	case runtime.PtrTo(typ).Implements(unmarshalTextType):
 		return false
+	case runtime.PtrTo(typ).Implements(unmarshalDecimalType):
+		return false
 	}
 	switch typ.Kind() {
 	case reflect.Map:
@@ -352,16 +413,19 @@ func compileStruct(typ *runtime.Type, structName, fieldName string, structTypeTo
 					// recursive definition
 					continue
 				}
-				for k, v := range stDec.fieldMap {
+				for _, v := range stDec.fieldSets {
+					k := v.key
 					if tags.ExistsKey(k) {
 						continue
 					}
 					fieldSet := &structFieldSet{
 						dec:         v.dec,
+						typ:         v.typ,
 						offset:      field.Offset + v.offset,
 						isTaggedKey: v.isTaggedKey,
 						key:         k,
 						keyLen:      int64(len(k)),
+						aliases:     v.aliases,
 					}
 					allFields = append(allFields, fieldSet)
 				}
@@ -379,16 +443,19 @@ func compileStruct(typ *runtime.Type, structName, fieldName string, structTypeTo
 					)
 				}
 				if dec, ok := contentDec.(*structDecoder); ok {
-					for k, v := range dec.fieldMap {
+					for _, v := range dec.fieldSets {
+						k := v.key
 						if tags.ExistsKey(k) {
 							continue
 						}
 						fieldSet := &structFieldSet{
 							dec:         newAnonymousFieldDecoder(pdec.typ, v.offset, v.dec),
+							typ:         v.typ,
 							offset:      field.Offset,
 							isTaggedKey: v.isTaggedKey,
 							key:         k,
 							keyLen:      int64(len(k)),
+							aliases:     v.aliases,
 							err:         fieldSetErr,
 						}
 						allFields = append(allFields, fieldSet)
@@ -396,20 +463,24 @@ func compileStruct(typ *runtime.Type, structName, fieldName string, structTypeTo
 				} else {
 					fieldSet := &structFieldSet{
 						dec:         pdec,
+						typ:         runtime.Type2RType(field.Type),
 						offset:      field.Offset,
 						isTaggedKey: tag.IsTaggedKey,
 						key:         field.Name,
 						keyLen:      int64(len(field.Name)),
+						aliases:     tag.Aliases,
 					}
 					allFields = append(allFields, fieldSet)
 				}
 			} else {
 				fieldSet := &structFieldSet{
 					dec:         dec,
+					typ:         runtime.Type2RType(field.Type),
 					offset:      field.Offset,
 					isTaggedKey: tag.IsTaggedKey,
 					key:         field.Name,
 					keyLen:      int64(len(field.Name)),
+					aliases:     tag.Aliases,
 				}
 				allFields = append(allFields, fieldSet)
 			}
@@ -425,15 +496,18 @@ func compileStruct(typ *runtime.Type, structName, fieldName string, structTypeTo
 			}
 			fieldSet := &structFieldSet{
 				dec:         dec,
+				typ:         runtime.Type2RType(field.Type),
 				offset:      field.Offset,
 				isTaggedKey: tag.IsTaggedKey,
 				key:         key,
 				keyLen:      int64(len(key)),
+				aliases:     tag.Aliases,
 			}
 			allFields = append(allFields, fieldSet)
 		}
 	}
-	for _, set := range filterDuplicatedFields(allFields) {
+	structDec.fieldSets = filterDuplicatedFields(allFields)
+	for _, set := range structDec.fieldSets {
 		fieldMap[set.key] = set
 		lower := strings.ToLower(set.key)
 		if _, exists := fieldMap[lower]; !exists {
@@ -0,0 +1,161 @@
+package decode
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+// DecimalUnmarshaler is implemented by types that decode an arrow decimal
+// losslessly from its unscaled integer value and scale, the decoded number
+// being unscaled * 10^-scale.
+type DecimalUnmarshaler interface {
+	UnmarshalDecimal(unscaled *big.Int, scale int32) error
+}
+
+var (
+	unmarshalDecimalType = reflect.TypeOf((*DecimalUnmarshaler)(nil)).Elem()
+	bigIntType           = runtime.Type2RType(reflect.TypeOf(big.Int{}))
+	bigRatType           = runtime.Type2RType(reflect.TypeOf(big.Rat{}))
+	bigFloatType         = runtime.Type2RType(reflect.TypeOf(big.Float{}))
+)
+
+// unmarshalDecimalDecoder decodes types implementing DecimalUnmarshaler only.
+// Types also implementing json.Unmarshaler or encoding.TextUnmarshaler are
+// decoded by those decoders, which check for DecimalUnmarshaler first.
+type unmarshalDecimalDecoder struct {
+	typ        *runtime.Type
+	structName string
+	fieldName  string
+}
+
+func newUnmarshalDecimalDecoder(typ *runtime.Type, structName, fieldName string) *unmarshalDecimalDecoder {
+	return &unmarshalDecimalDecoder{
+		typ:        typ,
+		structName: structName,
+		fieldName:  fieldName,
+	}
+}
+
+func (d *unmarshalDecimalDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	v := *(*interface{})(unsafe.Pointer(&emptyInterface{
+		typ: d.typ,
+		ptr: p,
+	}))
+	if ok, err := decodeDecimal(v, arr, i); ok {
+		return err
+	}
+	return errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.typ.Elem()), -1)
+}
+
+func (d *unmarshalDecimalDecoder) Decode(ctx *RuntimeContext, cursor, depth int64, p unsafe.Pointer) (int64, error) {
+	return 0, fmt.Errorf("json: decimal decoder does not support decode")
+}
+
+func (d *unmarshalDecimalDecoder) DecodePath(ctx *RuntimeContext, cursor, depth int64) ([][]byte, int64, error) {
+	return nil, 0, fmt.Errorf("json: decimal decoder does not support decode path")
+}
+
+// decimalValue returns the i-th value of a decimal layout as its unscaled
+// integer value and scale.
+func decimalValue(arr arrow.Array, i int) (*big.Int, int32, bool) {
+	switch a := arr.(type) {
+	case *array.Decimal128:
+		return a.Value(i).BigInt(), a.DataType().(*arrow.Decimal128Type).Scale, true
+	case *array.Decimal256:
+		return a.Value(i).BigInt(), a.DataType().(*arrow.Decimal256Type).Scale, true
+	}
+	return nil, 0, false
+}
+
+// decodeDecimal decodes the i-th value of a decimal array into v, reporting
+// whether v is a pointer to a type able to hold decimals.
+func decodeDecimal(v interface{}, arr arrow.Array, i int) (bool, error) {
+	unscaled, scale, ok := decimalValue(arr, i)
+	if !ok {
+		return false, nil
+	}
+	switch v := v.(type) {
+	case DecimalUnmarshaler:
+		return true, v.UnmarshalDecimal(unscaled, scale)
+	case *big.Int:
+		if scale <= 0 {
+			v.Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-scale)), nil))
+			return true, nil
+		}
+		q, r := new(big.Int).QuoRem(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil), new(big.Int))
+		if r.Sign() != 0 {
+			// the fractional digits do not fit into an integer
+			err := errors.ErrArrowType("", arr.DataType(), reflect.TypeOf(big.Int{}), -1)
+			err.Value = decimalString(unscaled, scale)
+			return true, err
+		}
+		v.Set(q)
+		return true, nil
+	case *big.Rat:
+		v.Set(decimalRat(unscaled, scale))
+		return true, nil
+	case *big.Float:
+		// 64 bits more than the unscaled value keep every decimal digit, the
+		// quotient rounding to nearest, whatever the precision v was set to
+		if prec := uint(unscaled.BitLen()) + 64; v.Prec() < prec {
+			v.SetPrec(prec)
+		}
+		v.SetRat(decimalRat(unscaled, scale))
+		return true, nil
+	}
+	return false, nil
+}
+
+// isDecimalGoType reports whether decodeDecimal decodes into values of the pointer type typ.
+func isDecimalGoType(typ *runtime.Type) bool {
+	if typ.Implements(unmarshalDecimalType) {
+		return true
+	}
+	switch typ.Elem() {
+	case bigIntType, bigRatType, bigFloatType:
+		return true
+	}
+	return false
+}
+
+func isDecimalType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.DECIMAL128, arrow.DECIMAL256:
+		return true
+	}
+	return false
+}
+
+func decimalRat(unscaled *big.Int, scale int32) *big.Rat {
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs32(scale))), nil)
+	if scale < 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(unscaled, pow))
+	}
+	return new(big.Rat).SetFrac(unscaled, pow)
+}
+
+// decimalString formats unscaled * 10^-scale in plain decimal notation.
+func decimalString(unscaled *big.Int, scale int32) string {
+	if scale <= 0 {
+		return decimalRat(unscaled, scale).RatString()
+	}
+	return decimalRat(unscaled, scale).FloatString(int(scale))
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,118 @@
+package decode
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+)
+
+// extensionArrowDecoder decodes the storage of extension arrays.
+type extensionArrowDecoder struct {
+	elem arrowDecoder
+}
+
+func (d *extensionArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.elem.decode(arr.(array.ExtensionArray).Storage(), i, p)
+}
+
+func (d *extensionArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return d.elem.decodeRange(arr.(array.ExtensionArray).Storage(), from, to, p, stride)
+}
+
+// Extension is a registered extension type: the Go type of its values, the
+// arrow type of its columns and the functions converting values from and to
+// the storage of the columns.
+type Extension struct {
+	GoType      reflect.Type
+	Type        arrow.ExtensionType
+	Serialize   func(b array.Builder, v any) error
+	Deserialize func(arr arrow.Array, i int, v any) error
+}
+
+var extensions = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*Extension
+	byName map[string]*Extension
+}{
+	byType: map[reflect.Type]*Extension{},
+	byName: map[string]*Extension{},
+}
+
+// RegisterExtension adds ext to the registry. It fails when its Go type or
+// extension name is already registered.
+func RegisterExtension(ext *Extension) error {
+	extensions.Lock()
+	defer extensions.Unlock()
+	if _, ok := extensions.byType[ext.GoType]; ok {
+		return fmt.Errorf("extension for type %s already registered", ext.GoType)
+	}
+	name := ext.Type.ExtensionName()
+	if _, ok := extensions.byName[name]; ok {
+		return fmt.Errorf("extension %s already registered", name)
+	}
+	extensions.byType[ext.GoType] = ext
+	extensions.byName[name] = ext
+	return nil
+}
+
+// UnregisterExtension removes ext, added by RegisterExtension, from the
+// registry.
+func UnregisterExtension(ext *Extension) {
+	extensions.Lock()
+	defer extensions.Unlock()
+	if extensions.byType[ext.GoType] == ext {
+		delete(extensions.byType, ext.GoType)
+		delete(extensions.byName, ext.Type.ExtensionName())
+	}
+}
+
+// LookupExtension returns the extension registered for t, nil when there is
+// none.
+func LookupExtension(t reflect.Type) *Extension {
+	extensions.RLock()
+	defer extensions.RUnlock()
+	return extensions.byType[t]
+}
+
+// Accepts reports whether columns of type dt hold values of ext: columns of
+// its extension type, or of its storage type once the annotation is lost.
+func (ext *Extension) Accepts(dt arrow.DataType) bool {
+	if et, ok := dt.(arrow.ExtensionType); ok {
+		return et.ExtensionName() == ext.Type.ExtensionName() && arrow.TypeEqual(et.StorageType(), ext.Type.StorageType())
+	}
+	return arrow.TypeEqual(dt, ext.Type.StorageType())
+}
+
+// extensionDecoder decodes arrow values of registered extension types through
+// their Deserialize function, and JSON values through dec, the decoder the
+// type would have without the extension.
+type extensionDecoder struct {
+	dec Decoder
+	ext *Extension
+}
+
+func newExtensionDecoder(dec Decoder, ext *Extension) *extensionDecoder {
+	return &extensionDecoder{dec: dec, ext: ext}
+}
+
+func (d *extensionDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	if ea, ok := arr.(array.ExtensionArray); ok {
+		arr = ea.Storage()
+	}
+	return d.ext.Deserialize(arr, i, reflect.NewAt(d.ext.GoType, p).Interface())
+}
+
+func (d *extensionDecoder) Decode(ctx *RuntimeContext, cursor, depth int64, p unsafe.Pointer) (int64, error) {
+	return d.dec.Decode(ctx, cursor, depth, p)
+}
+
+func (d *extensionDecoder) DecodePath(ctx *RuntimeContext, cursor, depth int64) ([][]byte, int64, error) {
+	return d.dec.DecodePath(ctx, cursor, depth)
+}
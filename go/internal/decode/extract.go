@@ -0,0 +1,253 @@
+package decode
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+// Extract evaluates the JSONPath path against each row of record, the root
+// $ being the row, and decodes the values matched into v. Paths are walked
+// over the arrow arrays, struct children, map entries and list offsets, so
+// only the values matched are decoded. A slice v receives every value
+// matched, in row order, and any other v the first one. A list matched is one
+// value, so it extracts into an element of a slice of slices.
+func Extract(record arrow.Record, path string, v interface{}, opt *Option) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.ErrInvalidExtract(reflect.TypeOf(v))
+	}
+	p, err := PathString(path).Build()
+	if err != nil {
+		return err
+	}
+
+	dst := rv.Elem()
+	all := dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() != reflect.Uint8
+	e := &extractor{
+		path:     path,
+		opt:      opt,
+		decoders: map[string]arrowDecoder{},
+		typ:      dst.Type(),
+	}
+	if all {
+		e.typ = dst.Type().Elem()
+		e.values = dst.Slice(0, 0)
+	}
+
+	rows := array.RecordToStructArray(record)
+	defer rows.Release()
+	root := arrow.Field{Name: "$", Type: rows.DataType()}
+	for i := 0; i < int(record.NumRows()); i++ {
+		if err := e.walk(p.node, root, rows, i); err != nil {
+			return annotateRow(err, i)
+		}
+		if !all && e.matched {
+			break
+		}
+	}
+	if all {
+		dst.Set(e.values)
+	} else if e.matched {
+		dst.Set(e.value)
+	}
+	return nil
+}
+
+// extractor collects the values matched by a path into values, or value for
+// the first one only.
+type extractor struct {
+	path     string
+	opt      *Option
+	typ      reflect.Type
+	decoders map[string]arrowDecoder
+	values   reflect.Value
+	value    reflect.Value
+	matched  bool
+}
+
+// walk applies node, nil once the whole path is matched, to the i-th value
+// of arr, the array of field f.
+func (e *extractor) walk(node PathNode, f arrow.Field, arr arrow.Array, i int) error {
+	if node == nil {
+		return e.match(f, arr, i)
+	}
+	arr, i = resolveValue(arr, i)
+	if arr.IsNull(i) {
+		return nil
+	}
+	switch n := node.(type) {
+	case *PathSelectorNode:
+		switch a := arr.(type) {
+		case *array.Struct:
+			idx, ok := structFieldIndex(a.DataType().(*arrow.StructType), n.selector)
+			if !ok {
+				return errors.ErrInvalidPath("no field %s in %s of type %s", n.selector, f.Name, arr.DataType())
+			}
+			return e.walk(n.child, a.DataType().(*arrow.StructType).Field(idx), a.Field(idx), i)
+		case *array.Map:
+			start, end := a.ValueOffsets(i)
+			keys, items := a.Keys(), a.Items()
+			for j := int(start); j < int(end); j++ {
+				if key, ok := stringValue(keys, j); ok && key == n.selector {
+					return e.walk(n.child, a.DataType().(*arrow.MapType).ItemField(), items, j)
+				}
+			}
+			return nil
+		}
+	case *PathIndexNode:
+		if values, start, end, ok := listValueRange(arr, i); ok {
+			if n.selector < 0 || start+n.selector >= end {
+				return nil
+			}
+			return e.walk(n.child, listElemField(arr.DataType()), values, start+n.selector)
+		}
+	case *PathIndexAllNode:
+		if values, start, end, ok := listValueRange(arr, i); ok {
+			elem := listElemField(arr.DataType())
+			for j := start; j < end; j++ {
+				if err := e.walk(n.child, elem, values, j); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case *PathRecursiveNode:
+		return e.walkRecursive(n, arr, i)
+	}
+	return errors.ErrInvalidPath("%s does not apply to %s of type %s", node, f.Name, arr.DataType())
+}
+
+// walkRecursive applies the child of n to the fields named after its
+// selector in the i-th value of arr and in all its descendants.
+func (e *extractor) walkRecursive(n *PathRecursiveNode, arr arrow.Array, i int) error {
+	arr, i = resolveValue(arr, i)
+	if arr.IsNull(i) {
+		return nil
+	}
+	switch a := arr.(type) {
+	case *array.Struct:
+		for idx, f := range a.DataType().(*arrow.StructType).Fields() {
+			if f.Name == n.selector {
+				if err := e.walk(n.child, f, a.Field(idx), i); err != nil {
+					return err
+				}
+			}
+			if err := e.walkRecursive(n, a.Field(idx), i); err != nil {
+				return err
+			}
+		}
+	case *array.Map:
+		start, end := a.ValueOffsets(i)
+		keys, items := a.Keys(), a.Items()
+		item := a.DataType().(*arrow.MapType).ItemField()
+		for j := int(start); j < int(end); j++ {
+			if key, ok := stringValue(keys, j); ok && key == n.selector {
+				if err := e.walk(n.child, item, items, j); err != nil {
+					return err
+				}
+			}
+			if err := e.walkRecursive(n, items, j); err != nil {
+				return err
+			}
+		}
+	default:
+		if values, start, end, ok := listValueRange(arr, i); ok {
+			for j := start; j < end; j++ {
+				if err := e.walkRecursive(n, values, j); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// match decodes the i-th value of arr, the array of field f, as a value
+// matched by the path.
+func (e *extractor) match(f arrow.Field, arr arrow.Array, i int) error {
+	dec, err := e.decoder(f)
+	if err != nil {
+		if _, ok := listElemType(f.Type); ok && e.values.IsValid() {
+			// each list matched is one element of the slice
+			return errors.ErrInvalidPath("%s matches lists, which extract into a slice of %s: extract into a slice of slices, or select their elements with [*]", e.path, e.typ)
+		}
+		return err
+	}
+	var p reflect.Value
+	if e.values.IsValid() {
+		e.values = reflect.Append(e.values, reflect.Zero(e.typ))
+		p = e.values.Index(e.values.Len() - 1).Addr()
+	} else {
+		p = reflect.New(e.typ)
+		e.value = p.Elem()
+	}
+	e.matched = true
+	return dec.decode(arr, i, p.UnsafePointer())
+}
+
+// decoder returns the decoder of the values of field f, compiled once for
+// each column type and layout matched.
+func (e *extractor) decoder(f arrow.Field) (arrowDecoder, error) {
+	layout := FieldLayout(f, "")
+	key := f.Type.String() + "\x00" + layout
+	if dec, ok := e.decoders[key]; ok {
+		return dec, nil
+	}
+	typ := runtime.Type2RType(e.typ)
+	elemDec, err := CompileToGetDecoder(runtime.PtrTo(typ))
+	if err != nil {
+		return nil, err
+	}
+	dec, err := compileArrowDecoder(typ, elemDec, f.Type, e.path, layout, e.opt)
+	if err != nil {
+		return nil, err
+	}
+	dec = withNullPolicy(dec, typ, "", "", e.opt)
+	e.decoders[key] = dec
+	return dec, nil
+}
+
+// resolveValue returns the array and index holding the i-th value of arr
+// once extension arrays are unwrapped to their storage and unions to the
+// child of the value.
+func resolveValue(arr arrow.Array, i int) (arrow.Array, int) {
+	for {
+		switch a := arr.(type) {
+		case array.ExtensionArray:
+			arr = a.Storage()
+		case array.Union:
+			arr, i = unionChild(a, i)
+		default:
+			return arr, i
+		}
+	}
+}
+
+// structFieldIndex returns the index of the field of st named name, else
+// matching it case-insensitively.
+func structFieldIndex(st *arrow.StructType, name string) (int, bool) {
+	if idx, ok := st.FieldIdx(name); ok {
+		return idx, true
+	}
+	for idx, f := range st.Fields() {
+		if strings.EqualFold(f.Name, name) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// listElemField returns the element field of the list type dt.
+func listElemField(dt arrow.DataType) arrow.Field {
+	if list, ok := dt.(interface{ ElemField() arrow.Field }); ok {
+		return list.ElemField()
+	}
+	elem, _ := listElemType(dt)
+	return arrow.Field{Name: "item", Type: elem, Nullable: true}
+}
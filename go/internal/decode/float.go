@@ -1,11 +1,11 @@
 package decode
 
 import (
+	"reflect"
 	"strconv"
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 )
@@ -122,14 +122,14 @@ func (d *floatDecoder) decodeByte(buf []byte, cursor int64) ([]byte, int64, erro
 }
 
 func (d *floatDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
-	if arr.IsValid(i) {
-		switch arr.(type) {
-		case *array.Float64:
-			d.op(p, arr.(*array.Float64).Value(i))
-		case *array.Float32:
-			d.op(p, float64(arr.(*array.Float32).Value(i)))
-		}
+	if arr.IsNull(i) {
+		return nil
+	}
+	v, ok := float64Value(arr, i)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), reflect.TypeOf(v), -1)
 	}
+	d.op(p, v)
 	return nil
 }
 
@@ -3,10 +3,10 @@ package decode
 import (
 	"fmt"
 	"reflect"
+	"time"
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -185,16 +185,16 @@ func (d *intDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error
 	if arr.IsNull(i) {
 		return nil
 	}
-	switch d.kind {
-	case reflect.Int8:
-		d.op(p, int64(arr.(*array.Int8).Value(i)))
-	case reflect.Int16:
-		d.op(p, int64(arr.(*array.Int16).Value(i)))
-	case reflect.Int32:
-		d.op(p, int64(arr.(*array.Int32).Value(i)))
-	default:
-		d.op(p, arr.(*array.Int64).Value(i))
+	v, ok := int64Value(arr, i)
+	if !ok && d.typ == durationType {
+		var dur time.Duration
+		dur, ok = durationValue(arr, i)
+		v = int64(dur)
+	}
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.typ), -1)
 	}
+	d.op(p, v)
 	return nil
 }
 
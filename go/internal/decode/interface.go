@@ -24,9 +24,42 @@ type interfaceDecoder struct {
 	stringDecoder *stringDecoder
 }
 
-func (d *interfaceDecoder) DecodeArray(array arrow.Array, i int, pointer unsafe.Pointer) error {
-	//TODO implement me
-	panic("implement me")
+func (d *interfaceDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.decodeArrayIn(arr, i, p, ColumnTimeLocation)
+}
+
+func (d *interfaceDecoder) decodeArrayIn(arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
+	runtimeInterfaceValue := *(*interface{})(unsafe.Pointer(&emptyInterface{
+		typ: d.typ,
+		ptr: p,
+	}))
+	rv := reflect.ValueOf(runtimeInterfaceValue)
+	if arr.IsNull(i) {
+		**(**interface{})(unsafe.Pointer(&p)) = nil
+		return nil
+	}
+	if rv.NumMethod() > 0 && rv.CanInterface() {
+		return errors.ErrArrowType("", arr.DataType(), rv.Type(), -1)
+	}
+
+	iface := rv.Interface()
+	ifaceHeader := (*emptyInterface)(unsafe.Pointer(&iface))
+	typ := ifaceHeader.typ
+	if ifaceHeader.ptr == nil || d.typ == typ || typ == nil ||
+		typ.Kind() == reflect.Ptr && typ.Elem() == d.typ || typ.Kind() != reflect.Ptr {
+		// concrete type is empty interface
+		v, err := arrowValue(arr, i, loc)
+		if err != nil {
+			return err
+		}
+		*(*interface{})(p) = v
+		return nil
+	}
+	decoder, err := CompileToGetDecoder(typ)
+	if err != nil {
+		return err
+	}
+	return decodeArrayWith(decoder, arr, i, ifaceHeader.ptr, loc)
 }
 
 func newEmptyInterfaceDecoder(structName, fieldName string) *interfaceDecoder {
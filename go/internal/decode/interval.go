@@ -0,0 +1,44 @@
+package decode
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+var monthDayNanoIntervalType = runtime.Type2RType(reflect.TypeOf(arrow.MonthDayNanoInterval{}))
+
+// intervalDecoder decodes arrow.MonthDayNanoInterval, from interval columns
+// or, like any other struct, from JSON objects.
+type intervalDecoder struct {
+	structDecoder Decoder
+}
+
+func newIntervalDecoder(structDecoder Decoder) *intervalDecoder {
+	return &intervalDecoder{structDecoder: structDecoder}
+}
+
+func (d *intervalDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	a, ok := arr.(*array.MonthDayNanoInterval)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(monthDayNanoIntervalType), -1)
+	}
+	*(*arrow.MonthDayNanoInterval)(p) = a.Value(i)
+	return nil
+}
+
+func (d *intervalDecoder) Decode(ctx *RuntimeContext, cursor, depth int64, p unsafe.Pointer) (int64, error) {
+	return d.structDecoder.Decode(ctx, cursor, depth, p)
+}
+
+func (d *intervalDecoder) DecodePath(ctx *RuntimeContext, cursor, depth int64) ([][]byte, int64, error) {
+	return d.structDecoder.DecodePath(ctx, cursor, depth)
+}
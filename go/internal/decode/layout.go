@@ -0,0 +1,181 @@
+package decode
+
+import (
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+// EncodingMetadataKey is the field metadata key, owned by the client, of the
+// encoding of the values of string columns: JSONEncoding for values stored as
+// JSON text, such as recursive types truncated by schema derivation. The
+// LAYOUT metadata key is left to time layouts.
+const (
+	EncodingMetadataKey = "chronowave.client.encoding"
+	JSONEncoding        = "json"
+)
+
+// JSONLayout is the layout FieldLayout returns for string columns holding
+// values as JSON text. It is no time layout, so no LAYOUT metadata clashes
+// with it.
+const JSONLayout = "\x00json"
+
+// epochLayouts are the layouts of times stored as integer counts of a unit since the Unix epoch.
+var epochLayouts = map[string]time.Duration{
+	"unix":      time.Second,
+	"unixmilli": time.Millisecond,
+	"unixmicro": time.Microsecond,
+	"unixnano":  time.Nanosecond,
+}
+
+// TimeLayout parses and formats times stored in string or integer columns, as
+// recorded in the LAYOUT metadata of their field. Integer columns hold epoch
+// counts of milliseconds unless the layout names another epoch unit.
+type TimeLayout struct {
+	layout string
+	epoch  time.Duration
+}
+
+// NewTimeLayout returns the layout named by the LAYOUT metadata value layout,
+// time.RFC3339Nano when it is empty.
+func NewTimeLayout(layout string) TimeLayout {
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	return TimeLayout{layout: layout, epoch: epochLayouts[layout]}
+}
+
+// FormatInt returns t as an epoch count for integer columns.
+func (l TimeLayout) FormatInt(t time.Time) int64 {
+	switch l.epoch {
+	case time.Second:
+		return t.Unix()
+	case time.Microsecond:
+		return t.UnixMicro()
+	case time.Nanosecond:
+		return t.UnixNano()
+	}
+	return t.UnixMilli()
+}
+
+// FormatString returns t formatted for string columns.
+func (l TimeLayout) FormatString(t time.Time) string {
+	if l.epoch != 0 {
+		return strconv.FormatInt(l.FormatInt(t), 10)
+	}
+	return t.Format(l.layout)
+}
+
+// IsEpoch reports whether the layout stores times as epoch counts.
+func (l TimeLayout) IsEpoch() bool {
+	return l.epoch != 0
+}
+
+// Parse parses s as the value of a string column, keeping the zone of s.
+func (l TimeLayout) Parse(s string) (time.Time, error) {
+	return l.parseString(s, nil)
+}
+
+// parseInt returns the time of the epoch count v in loc, UTC when loc is nil.
+func (l TimeLayout) parseInt(v int64, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	switch l.epoch {
+	case time.Second:
+		return time.Unix(v, 0).In(loc)
+	case time.Microsecond:
+		return time.UnixMicro(v).In(loc)
+	case time.Nanosecond:
+		return time.Unix(0, v).In(loc)
+	}
+	return time.UnixMilli(v).In(loc)
+}
+
+// parseString parses s in loc, keeping the zone of s when loc is nil.
+func (l TimeLayout) parseString(s string, loc *time.Location) (time.Time, error) {
+	if l.epoch != 0 {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return l.parseInt(v, loc), nil
+	}
+	if loc == nil {
+		// UTC when the layout has no zone
+		return time.Parse(l.layout, s)
+	}
+	t, err := time.ParseInLocation(l.layout, s, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(loc), nil
+}
+
+// layoutTimeArrowDecoder decodes time.Time from string or integer columns,
+// in loc unless it is nil.
+type layoutTimeArrowDecoder struct {
+	layout TimeLayout
+	loc    *time.Location
+}
+
+func (d *layoutTimeArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	if v, ok := int64Value(arr, i); ok {
+		*(*time.Time)(p) = d.layout.parseInt(v, d.loc)
+		return nil
+	}
+	s, _ := stringValue(arr, i)
+	t, err := d.layout.parseString(s, d.loc)
+	if err != nil {
+		e := errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(timeType), -1)
+		e.Value = strconv.Quote(s)
+		return e
+	}
+	*(*time.Time)(p) = t
+	return nil
+}
+
+func (d *layoutTimeArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// jsonTextArrowDecoder decodes string columns with JSONLayout through the JSON
+// decoder of the Go type.
+type jsonTextArrowDecoder struct {
+	typ *runtime.Type
+	dec Decoder
+}
+
+func (d *jsonTextArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	s, _ := stringValue(arr, i)
+	// decoded strings share the buffer, which must outlive the context
+	buf := make([]byte, len(s)+1)
+	copy(buf, s)
+	ctx := TakeRuntimeContext()
+	oldBuf := ctx.Buf
+	ctx.Buf = buf
+	_, err := d.dec.Decode(ctx, 0, 0, p)
+	ctx.Buf = oldBuf
+	ReleaseRuntimeContext(ctx)
+	if err != nil {
+		e := errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.typ), -1)
+		e.Value = strconv.Quote(s)
+		return e
+	}
+	return nil
+}
+
+func (d *jsonTextArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
@@ -5,6 +5,7 @@ import (
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -68,61 +69,49 @@ func (d *mapDecoder) mapassign(t *runtime.Type, m, k, v unsafe.Pointer) {
 	}
 }
 
-func (d *mapDecoder) DecodeArray(s arrow.Array, depth int, p unsafe.Pointer) error {
-	/*
-		depth++
-		if depth > maxDecodeNestingDepth {
-			return errors.ErrExceededMaxDepth(s.char(), s.cursor)
-		}
+func (d *mapDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.decodeArrayIn(arr, i, p, ColumnTimeLocation)
+}
 
-		switch s.skipWhiteSpace() {
-		case 'n':
-			if err := nullBytes(s); err != nil {
-				return err
+func (d *mapDecoder) decodeArrayIn(arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
+	if arr.IsNull(i) {
+		**(**unsafe.Pointer)(unsafe.Pointer(&p)) = nil
+		return nil
+	}
+	mapValue := *(*unsafe.Pointer)(p)
+	if mapValue == nil {
+		mapValue = makemap(d.mapType, 0)
+	}
+	switch a := arr.(type) {
+	case *array.Struct:
+		// struct fields are keyed by their column name
+		for idx, f := range a.DataType().(*arrow.StructType).Fields() {
+			k := unsafe_New(d.keyType)
+			*(*string)(k) = f.Name
+			v := unsafe_New(d.valueType)
+			if err := decodeArrayWith(d.valueDecoder, a.Field(idx), i, v, loc); err != nil {
+				return annotateColumn(err, f.Name)
 			}
-			**(**unsafe.Pointer)(unsafe.Pointer(&p)) = nil
-			return nil
-		case '{':
-		default:
-			return errors.ErrExpected("{ character for map value", s.totalOffset())
-		}
-		mapValue := *(*unsafe.Pointer)(p)
-		if mapValue == nil {
-			mapValue = makemap(d.mapType, 0)
-		}
-		s.cursor++
-		if s.equalChar('}') {
-			*(*unsafe.Pointer)(p) = mapValue
-			s.cursor++
-			return nil
+			d.mapassign(d.mapType, mapValue, k, v)
 		}
-		for {
+	case *array.Map:
+		start, end := a.ValueOffsets(i)
+		keys, items := a.Keys(), a.Items()
+		for idx := int(start); idx < int(end); idx++ {
 			k := unsafe_New(d.keyType)
-			if err := d.keyDecoder.DecodeArray(s, depth, k); err != nil {
-				return err
-			}
-			s.skipWhiteSpace()
-			if !s.equalChar(':') {
-				return errors.ErrExpected("colon after object key", s.totalOffset())
+			if err := decodeArrayWith(d.keyDecoder, keys, idx, k, loc); err != nil {
+				return annotateElement(err)
 			}
-			s.cursor++
 			v := unsafe_New(d.valueType)
-			if err := d.valueDecoder.DecodeArray(s, depth, v); err != nil {
-				return err
+			if err := decodeArrayWith(d.valueDecoder, items, idx, v, loc); err != nil {
+				return annotateElement(err)
 			}
 			d.mapassign(d.mapType, mapValue, k, v)
-			s.skipWhiteSpace()
-			if s.equalChar('}') {
-				**(**unsafe.Pointer)(unsafe.Pointer(&p)) = mapValue
-				s.cursor++
-				return nil
-			}
-			if !s.equalChar(',') {
-				return errors.ErrExpected("comma after object value", s.totalOffset())
-			}
-			s.cursor++
 		}
-	*/
+	default:
+		return errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.mapType), -1)
+	}
+	**(**unsafe.Pointer)(unsafe.Pointer(&p)) = mapValue
 	return nil
 }
 
@@ -0,0 +1,204 @@
+package decode
+
+import (
+	"encoding/hex"
+	"net"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+var (
+	netipAddrType    = runtime.Type2RType(reflect.TypeOf(netip.Addr{}))
+	netipPrefixType  = runtime.Type2RType(reflect.TypeOf(netip.Prefix{}))
+	hardwareAddrType = runtime.Type2RType(reflect.TypeOf(net.HardwareAddr{}))
+)
+
+// PrefixByteWidth is the width of the FixedSizeBinary columns of netip.Prefix
+// values: the 16 bytes of the address followed by the prefix length.
+const PrefixByteWidth = 17
+
+// compileBinaryArrowDecoder returns the decoder of the Go types stored in
+// binary columns, byte arrays and network addresses, from columns of type dt.
+// ok is false for the other pairs of types, left to their usual decoders.
+func compileBinaryArrowDecoder(typ *runtime.Type, dt arrow.DataType) (arrowDecoder, bool) {
+	fsb, isFixed := dt.(*arrow.FixedSizeBinaryType)
+	switch {
+	case typ == netipAddrType:
+		if isFixed && (fsb.ByteWidth == 16 || fsb.ByteWidth == 4) {
+			return &addrArrowDecoder{}, true
+		}
+	case typ == netipPrefixType:
+		if isFixed && fsb.ByteWidth == PrefixByteWidth {
+			return &prefixArrowDecoder{}, true
+		}
+	case typ == hardwareAddrType:
+		if isTextType(dt) {
+			return &hardwareAddrArrowDecoder{}, true
+		}
+	case typ.Kind() == reflect.Array && typ.Elem().Kind() == reflect.Uint8:
+		if isFixed && fsb.ByteWidth == typ.Len() {
+			return &byteArrayArrowDecoder{size: typ.Len()}, true
+		}
+		if typ.Len() == 16 && isTextType(dt) && !implementsUnmarshalJSONType(runtime.PtrTo(typ)) && !runtime.PtrTo(typ).Implements(unmarshalTextType) {
+			return &uuidStringArrowDecoder{typ: typ}, true
+		}
+	}
+	return nil, false
+}
+
+// isTextType reports whether dt is a string layout, binary ones excluded.
+func isTextType(dt arrow.DataType) bool {
+	return dt.ID() == arrow.STRING || dt.ID() == arrow.LARGE_STRING
+}
+
+// addrArrowDecoder decodes netip.Addr from FixedSizeBinary(16) columns, IPv4
+// addresses being stored mapped to IPv6, or FixedSizeBinary(4) columns.
+type addrArrowDecoder struct{}
+
+func (d *addrArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	b := arr.(*array.FixedSizeBinary).Value(i)
+	if len(b) == 4 {
+		*(*netip.Addr)(p) = netip.AddrFrom4(*(*[4]byte)(b))
+	} else {
+		*(*netip.Addr)(p) = netip.AddrFrom16(*(*[16]byte)(b)).Unmap()
+	}
+	return nil
+}
+
+func (d *addrArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// prefixArrowDecoder decodes netip.Prefix from FixedSizeBinary columns of
+// PrefixByteWidth bytes.
+type prefixArrowDecoder struct{}
+
+func (d *prefixArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	b := arr.(*array.FixedSizeBinary).Value(i)
+	addr := netip.AddrFrom16(*(*[16]byte)(b)).Unmap()
+	bits := int(b[16])
+	if addr.Is4() && bits > 32 || bits > 128 {
+		e := errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(netipPrefixType), -1)
+		e.Value = "prefix length " + strconv.Itoa(bits)
+		return e
+	}
+	*(*netip.Prefix)(p) = netip.PrefixFrom(addr, bits)
+	return nil
+}
+
+func (d *prefixArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// hardwareAddrArrowDecoder decodes net.HardwareAddr from string columns in
+// any format net.ParseMAC accepts.
+type hardwareAddrArrowDecoder struct{}
+
+func (d *hardwareAddrArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		*(*net.HardwareAddr)(p) = nil
+		return nil
+	}
+	s, _ := stringValue(arr, i)
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		e := errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(hardwareAddrType), -1)
+		e.Value = strconv.Quote(s)
+		return e
+	}
+	*(*net.HardwareAddr)(p) = mac
+	return nil
+}
+
+func (d *hardwareAddrArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// byteArrayArrowDecoder decodes byte arrays, such as UUIDs, from
+// FixedSizeBinary columns of their size.
+type byteArrayArrowDecoder struct {
+	size int
+}
+
+func (d *byteArrayArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsValid(i) {
+		copy(unsafe.Slice((*byte)(p), d.size), arr.(*array.FixedSizeBinary).Value(i))
+	}
+	return nil
+}
+
+func (d *byteArrayArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// uuidStringArrowDecoder decodes 16 byte arrays from the text form of UUIDs.
+type uuidStringArrowDecoder struct {
+	typ *runtime.Type
+}
+
+func (d *uuidStringArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	s, _ := stringValue(arr, i)
+	uuid, ok := ParseUUID(s)
+	if !ok {
+		e := errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.typ), -1)
+		e.Value = strconv.Quote(s)
+		return e
+	}
+	*(*[16]byte)(p) = uuid
+	return nil
+}
+
+func (d *uuidStringArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// ParseUUID parses the text form of a UUID, 32 hexadecimal digits optionally
+// grouped 8-4-4-4-12 by hyphens.
+func ParseUUID(s string) ([16]byte, bool) {
+	var uuid [16]byte
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return uuid, false
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return uuid, false
+	}
+	if _, err := hex.Decode(uuid[:], []byte(s)); err != nil {
+		return uuid, false
+	}
+	return uuid, true
+}
+
+// FormatUUID returns the text form of uuid, grouped 8-4-4-4-12 by hyphens.
+func FormatUUID(uuid [16]byte) string {
+	var b [36]byte
+	hex.Encode(b[:8], uuid[:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], uuid[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], uuid[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], uuid[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], uuid[10:])
+	return string(b[:])
+}
@@ -6,6 +6,7 @@ import (
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 )
@@ -27,18 +28,30 @@ func newNumberDecoder(structName, fieldName string, op func(unsafe.Pointer, json
 }
 
 func (d *numberDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
-	//bytes, err := d.decodeStreamByte(s)
-	//if err != nil {
-	//return err
-	//}
-	//if _, err := strconv.ParseFloat(*(*string)(unsafe.Pointer(&bytes)), 64); err != nil {
-	//return errors.ErrSyntax(err.Error(), s.totalOffset())
-	//}
-	//switch arr.(type) {
-	//case *array.Float64:
-	//d.op(p, json.Number(string(bytes)))
-	//
-	//}
+	if arr.IsNull(i) {
+		return nil
+	}
+	switch a := arr.(type) {
+	case *array.Float32:
+		d.op(p, json.Number(strconv.FormatFloat(float64(a.Value(i)), 'g', -1, 32)))
+	case *array.Float64:
+		d.op(p, json.Number(strconv.FormatFloat(a.Value(i), 'g', -1, 64)))
+	case *array.Uint64:
+		d.op(p, json.Number(strconv.FormatUint(a.Value(i), 10)))
+	default:
+		if v, ok := int64Value(arr, i); ok {
+			d.op(p, json.Number(strconv.FormatInt(v, 10)))
+			return nil
+		}
+		str, ok := stringValue(arr, i)
+		if !ok {
+			return errors.ErrArrowType("", arr.DataType(), jsonNumberType, -1)
+		}
+		if _, err := strconv.ParseFloat(str, 64); err != nil {
+			return errors.ErrArrowType("", arr.DataType(), jsonNumberType, -1)
+		}
+		d.op(p, json.Number(str))
+	}
 	return nil
 }
 
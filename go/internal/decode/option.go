@@ -8,10 +8,69 @@ const (
 	FirstWinOption OptionFlags = 1 << iota
 	ContextOption
 	PathOption
+	// DisallowUnknownColumnsOption fails decoding records with a column
+	// matching no Go struct field.
+	DisallowUnknownColumnsOption
+	// RequireAllFieldsOption fails decoding records without the column of
+	// a Go struct field.
+	RequireAllFieldsOption
+	// ZeroCopyOption decodes string columns into strings, and string and
+	// binary columns into byte slices, aliasing the record buffers rather
+	// than copies of them.
+	ZeroCopyOption
+)
+
+// TimeLocation selects the location of time.Time values decoded from
+// arrow timestamp columns.
+type TimeLocation uint8
+
+const (
+	// ColumnTimeLocation uses the time zone of the column, UTC when it has none.
+	ColumnTimeLocation TimeLocation = iota
+	UTCTimeLocation
+	LocalTimeLocation
+)
+
+// NullPolicy selects how arrow nulls decode into Go struct fields other than
+// pointers, slices, maps and interfaces.
+type NullPolicy uint8
+
+const (
+	// ZeroNullPolicy sets the field to its zero value.
+	ZeroNullPolicy NullPolicy = iota
+	// KeepNullPolicy keeps the value the field holds.
+	KeepNullPolicy
+	// ErrorNullPolicy fails decoding.
+	ErrorNullPolicy
+)
+
+// SliceMode selects how decoding fills the slices it decodes rows into.
+type SliceMode uint8
+
+const (
+	// ReplaceSliceMode replaces the slice with a new one holding the rows.
+	ReplaceSliceMode SliceMode = iota
+	// AppendSliceMode appends the rows to the slice, in its spare capacity
+	// when it is large enough.
+	AppendSliceMode
+	// ReuseSliceMode decodes the rows into the elements of the slice when
+	// its capacity is large enough, reset to their zero value. The values
+	// pointer elements point to are reset and reused. With KeepNullPolicy
+	// the elements are not reset, so nulls keep the values they hold.
+	ReuseSliceMode
 )
 
 type Option struct {
-	Flags   OptionFlags
-	Context context.Context
-	Path    *Path
+	Flags        OptionFlags
+	Context      context.Context
+	Path         *Path
+	TimeLocation TimeLocation
+	NullPolicy   NullPolicy
+	SliceMode    SliceMode
+	// Workers is the number of goroutines decoding the rows of a record
+	// into a slice, below 2 for decoding them on the calling goroutine.
+	Workers int
+	// ChunkRows is the number of rows of the chunks workers take, 0 for
+	// a size derived from the number of rows and workers.
+	ChunkRows int
 }
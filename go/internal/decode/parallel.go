@@ -0,0 +1,69 @@
+package decode
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// minChunkRows is the least number of rows of a chunk decoded by a worker
+// when no chunk size is set, smaller chunks costing more to schedule than
+// to decode.
+const minChunkRows = 4096
+
+// decodeChunks calls decodeRange on chunks of chunkRows rows of [0, n),
+// taken by up to workers goroutines. Rows are decoded at once when workers
+// is below 2 or they fit in a chunk. An error stops the workers from taking
+// new chunks, and the rows are then decoded again at once: decodeRange may
+// decode column by column, so the error decoding the rows at once returns
+// need not be in the first failed chunk.
+func decodeChunks(n, workers, chunkRows int, decodeRange func(from, to int) error) error {
+	if workers < 2 {
+		return decodeRange(0, n)
+	}
+	if chunkRows <= 0 {
+		// a few chunks per worker balance rows slower to decode
+		chunkRows = (n + 4*workers - 1) / (4 * workers)
+		if chunkRows < minChunkRows {
+			chunkRows = minChunkRows
+		}
+	}
+	if n <= chunkRows {
+		return decodeRange(0, n)
+	}
+	chunks := (n + chunkRows - 1) / chunkRows
+	if workers > chunks {
+		workers = chunks
+	}
+
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				chunk := int(next.Add(1) - 1)
+				if chunk >= chunks {
+					return
+				}
+				from := chunk * chunkRows
+				to := from + chunkRows
+				if to > n {
+					to = n
+				}
+				if err := decodeRange(from, to); err != nil {
+					failed.Store(true)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if failed.Load() {
+		return decodeRange(0, n)
+	}
+	return nil
+}
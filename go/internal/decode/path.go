@@ -412,7 +412,7 @@ func (n *PathSelectorNode) Get(src, dst reflect.Value) error {
 		}
 	case reflect.Struct:
 		typ := src.Type()
-		for i := 0; i < typ.Len(); i++ {
+		for i := 0; i < typ.NumField(); i++ {
 			tag := runtime.StructTagFromField(typ.Field(i))
 			child, found, err := n.Field(tag.Key)
 			if err != nil {
@@ -552,13 +552,12 @@ type PathRecursiveNode struct {
 	selector string
 }
 
+// newPathRecursiveNode returns the node of ..selector, whose child applies
+// to the values of the fields named selector at any depth.
 func newPathRecursiveNode(selector string) *PathRecursiveNode {
-	node := newPathSelectorNode(selector)
 	return &PathRecursiveNode{
-		BasePathNode: &BasePathNode{
-			child: node,
-		},
-		selector: selector,
+		BasePathNode: &BasePathNode{},
+		selector:     selector,
 	}
 }
 
@@ -585,10 +584,39 @@ func valueToSliceValue(v interface{}) []interface{} {
 	return []interface{}{v}
 }
 
-func (n *PathRecursiveNode) Get(src, dst reflect.Value) error {
+// getMatch gets the value of a field matched by n into dst.
+func (n *PathRecursiveNode) getMatch(src, dst reflect.Value) error {
 	if n.child == nil {
-		return fmt.Errorf("failed to get by recursive path ..%s", n.selector)
+		return AssignValue(src, dst)
+	}
+	return n.child.Get(src, dst)
+}
+
+// appendMatches appends to arr the values n gets from the field key of value
+// src: the value itself, or what the child of n gets from it, when key is the
+// selector of n, and the values of the fields nested in src in any case, as
+// JSONPath selects fields at any depth. Values the child of n does not apply
+// to are skipped.
+func (n *PathRecursiveNode) appendMatches(arr *[]interface{}, key string, src reflect.Value) error {
+	_, found, err := n.Field(key)
+	if err != nil {
+		return err
 	}
+	if found {
+		var v interface{}
+		if err := n.getMatch(src, reflect.ValueOf(&v)); err == nil && v != nil {
+			*arr = append(*arr, valueToSliceValue(v)...)
+		}
+	}
+	var v interface{}
+	_ = n.Get(src, reflect.ValueOf(&v))
+	if v != nil {
+		*arr = append(*arr, valueToSliceValue(v)...)
+	}
+	return nil
+}
+
+func (n *PathRecursiveNode) Get(src, dst reflect.Value) error {
 	var arr []interface{}
 	switch src.Type().Kind() {
 	case reflect.Map:
@@ -598,47 +626,22 @@ func (n *PathRecursiveNode) Get(src, dst reflect.Value) error {
 			if !ok {
 				return fmt.Errorf("invalid map key type %T", src.Type().Key())
 			}
-			child, found, err := n.Field(key)
-			if err != nil {
+			if err := n.appendMatches(&arr, key, iter.Value()); err != nil {
 				return err
 			}
-			if found {
-				var v interface{}
-				rv := reflect.ValueOf(&v)
-				_ = child.Get(iter.Value(), rv)
-				arr = append(arr, valueToSliceValue(v)...)
-			} else {
-				var v interface{}
-				rv := reflect.ValueOf(&v)
-				_ = n.Get(iter.Value(), rv)
-				if v != nil {
-					arr = append(arr, valueToSliceValue(v)...)
-				}
-			}
 		}
 		_ = AssignValue(reflect.ValueOf(arr), dst)
 		return nil
 	case reflect.Struct:
 		typ := src.Type()
-		for i := 0; i < typ.Len(); i++ {
+		for i := 0; i < typ.NumField(); i++ {
+			if !typ.Field(i).IsExported() {
+				continue
+			}
 			tag := runtime.StructTagFromField(typ.Field(i))
-			child, found, err := n.Field(tag.Key)
-			if err != nil {
+			if err := n.appendMatches(&arr, tag.Key, src.Field(i)); err != nil {
 				return err
 			}
-			if found {
-				var v interface{}
-				rv := reflect.ValueOf(&v)
-				_ = child.Get(src.Field(i), rv)
-				arr = append(arr, valueToSliceValue(v)...)
-			} else {
-				var v interface{}
-				rv := reflect.ValueOf(&v)
-				_ = n.Get(src.Field(i), rv)
-				if v != nil {
-					arr = append(arr, valueToSliceValue(v)...)
-				}
-			}
 		}
 		_ = AssignValue(reflect.ValueOf(arr), dst)
 		return nil
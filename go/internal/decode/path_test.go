@@ -0,0 +1,53 @@
+package decode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathGetRecursive(t *testing.T) {
+	type leaf struct {
+		A int    `json:"a"`
+		B string `json:"b"`
+	}
+	type node struct {
+		A     *leaf  `json:"a"`
+		Items []leaf `json:"items"`
+		Name  string `json:"name"`
+	}
+	src := map[string]interface{}{
+		"root": node{
+			A:     &leaf{A: 1, B: "x"},
+			Items: []leaf{{A: 2, B: "y"}, {A: 3}},
+			Name:  "n",
+		},
+	}
+
+	tests := []struct {
+		path string
+		want []interface{}
+	}{
+		// fields at any depth, those nested in a matched value included
+		{path: "$..a", want: []interface{}{&leaf{A: 1, B: "x"}, 1, 2, 3}},
+		// the rest of the path applies to the values matched, skipping
+		// those it does not apply to
+		{path: "$..a.b", want: []interface{}{"x"}},
+		{path: "$..items[1].a", want: []interface{}{3}},
+		{path: "$..missing", want: nil},
+	}
+	for _, tc := range tests {
+		p, err := PathString(tc.path).Build()
+		if err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+			continue
+		}
+		var got []interface{}
+		if err := p.Get(reflect.ValueOf(src), reflect.ValueOf(&got)); err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("%s: want=%#v, got=%#v", tc.path, tc.want, got)
+		}
+	}
+}
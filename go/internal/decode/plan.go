@@ -0,0 +1,645 @@
+package decode
+
+import (
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+// arrowDecoder decodes the values of one arrow column whose layout was resolved
+// against the record schema when the plan was compiled.
+type arrowDecoder interface {
+	// decode decodes the i-th value of arr into p.
+	decode(arr arrow.Array, i int, p unsafe.Pointer) error
+	// decodeRange decodes the values [from, to) of arr into Go values stride
+	// bytes apart, p pointing at the value decoded from arr[from].
+	decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error
+}
+
+type planKey struct {
+	typ          uintptr
+	schema       string
+	columns      bool
+	timeLocation TimeLocation
+	flags        OptionFlags
+	nullPolicy   NullPolicy
+}
+
+func newPlanKey(typ *runtime.Type, schema *arrow.Schema, columns bool, opt *Option) planKey {
+	return planKey{
+		typ:          uintptr(unsafe.Pointer(typ)),
+		schema:       planSchemaKey(schema),
+		columns:      columns,
+		timeLocation: opt.TimeLocation,
+		flags:        opt.Flags,
+		nullPolicy:   opt.NullPolicy,
+	}
+}
+
+// schemaKey is the plan cache key of a schema.
+type schemaKey struct {
+	schema *arrow.Schema
+	key    string
+}
+
+var lastSchemaKey atomic.Pointer[schemaKey]
+
+// planSchemaKey identifies schema in the plan cache. Schema fingerprints leave
+// out field metadata and extension names, so the time layouts and extension
+// types plans depend on are appended to it. It returns "" for schemas without
+// a fingerprint, which are never cached.
+func planSchemaKey(schema *arrow.Schema) string {
+	// the records of a stream share their schema
+	if last := lastSchemaKey.Load(); last != nil && last.schema == schema {
+		return last.key
+	}
+	key := schema.Fingerprint()
+	if key == "" {
+		return ""
+	}
+	defer func() {
+		lastSchemaKey.Store(&schemaKey{schema: schema, key: key})
+	}()
+	var appendFields func(fields []arrow.Field)
+	appendType := func(dt arrow.DataType) {
+		if ext, ok := dt.(arrow.ExtensionType); ok {
+			key += "<" + ext.ExtensionName() + "=" + ext.Serialize() + ">"
+			dt = ext.StorageType()
+		}
+		if nested, ok := dt.(arrow.NestedType); ok {
+			key += "{"
+			appendFields(nested.Fields())
+			key += "}"
+		}
+	}
+	appendFields = func(fields []arrow.Field) {
+		for _, f := range fields {
+			if layout := FieldLayout(f, ""); layout != "" {
+				key += "|" + f.Name + "=" + layout
+			}
+			appendType(f.Type)
+		}
+	}
+	appendFields(schema.Fields())
+	return key
+}
+
+// recordPlan decodes a record into a slice of structs one column at a time.
+// It is compiled once per pair of Go type and record schema.
+type recordPlan struct {
+	rows       sliceRows
+	size       uintptr
+	structType *runtime.Type
+	fields     *structArrowDecoder
+	elem       arrowDecoder
+	columns    *columnsPlan
+}
+
+func compileToGetRecordPlan(typ *runtime.Type, schema *arrow.Schema, opt *Option) (*recordPlan, error) {
+	key := newPlanKey(typ, schema, false, opt)
+	planMap := loadPlanMap()
+	if plan, exists := planMap[key]; exists {
+		return plan, nil
+	}
+
+	plan, err := compileRecordPlan(typ, schema, opt)
+	if err != nil {
+		return nil, err
+	}
+	if key.schema != "" {
+		storePlan(key, plan, planMap)
+	}
+	return plan, nil
+}
+
+func compileRecordPlan(typ *runtime.Type, schema *arrow.Schema, opt *Option) (*recordPlan, error) {
+	dec, err := CompileToGetDecoder(typ)
+	if err != nil {
+		return nil, err
+	}
+	sliceDec, ok := dec.(*sliceDecoder)
+	if !ok {
+		return nil, errors.ErrInvalidRecordUnmarshal(runtime.RType2Type(typ))
+	}
+
+	elem, err := compileArrowDecoder(sliceDec.elemType, sliceDec.valueDecoder, arrow.StructOf(schema.Fields()...), "", "", opt)
+	if err != nil {
+		return nil, err
+	}
+	plan := &recordPlan{
+		rows: newSliceRows(sliceDec.elemType, sliceDec.size),
+		size: sliceDec.size,
+		elem: elem,
+	}
+	switch d := elem.(type) {
+	case *structArrowDecoder:
+		plan.fields = d
+	case *ptrArrowDecoder:
+		if fields, ok := d.elem.(*structArrowDecoder); ok {
+			plan.structType = d.typ
+			plan.fields = fields
+		}
+	}
+	return plan, nil
+}
+
+func (p *recordPlan) decode(record arrow.Record, ptr unsafe.Pointer, opt *Option) error {
+	n := int(record.NumRows())
+	data, reused := p.rows.prepare((*sliceHeader)(ptr), n, opt.SliceMode, opt.NullPolicy == KeepNullPolicy)
+
+	var decodeRange func(from, to int) error
+	switch {
+	case p.fields != nil && p.structType == nil:
+		decodeRange = func(from, to int) error {
+			return p.fields.decodeFields(record.Column, from, to, p.rows.elem(data, from), p.size)
+		}
+	case p.fields != nil && !reused:
+		// allocate all structs at once and decode them column by column
+		structs := newArray(p.structType, n)
+		structSize := p.structType.Size()
+		decodeRange = func(from, to int) error {
+			for i := from; i < to; i++ {
+				*(*unsafe.Pointer)(p.rows.elem(data, i)) = unsafe.Pointer(uintptr(structs) + uintptr(i)*structSize)
+			}
+			return p.fields.decodeFields(record.Column, from, to, unsafe.Pointer(uintptr(structs)+uintptr(from)*structSize), structSize)
+		}
+	default:
+		// reused pointer elements keep the values they point to
+		arr := array.RecordToStructArray(record)
+		defer arr.Release()
+		decodeRange = func(from, to int) error {
+			return p.elem.decodeRange(arr, from, to, p.rows.elem(data, from), p.size)
+		}
+	}
+	return decodeChunks(n, opt.Workers, opt.ChunkRows, decodeRange)
+}
+
+// decodeRangeByValue implements decodeRange on top of decode.
+func decodeRangeByValue(dec arrowDecoder, arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	for i := from; i < to; i++ {
+		if err := dec.decode(arr, i, unsafe.Pointer(uintptr(p)+uintptr(i-from)*stride)); err != nil {
+			return annotateRow(err, i)
+		}
+	}
+	return nil
+}
+
+// dynamicArrowDecoder adapts a Decoder which resolves the array layout per value in DecodeArray.
+type dynamicArrowDecoder struct {
+	dec Decoder
+	loc TimeLocation
+}
+
+func (d *dynamicArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return decodeArrayWith(d.dec, arr, i, p, d.loc)
+}
+
+func (d *dynamicArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+type structArrowField struct {
+	index  int
+	offset uintptr
+	name   string
+	dec    arrowDecoder
+}
+
+type structArrowDecoder struct {
+	fields []structArrowField
+}
+
+func (d *structArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	st := arr.(*array.Struct)
+	for _, f := range d.fields {
+		if err := f.dec.decode(st.Field(f.index), i, unsafe.Pointer(uintptr(p)+f.offset)); err != nil {
+			return annotateColumn(err, f.name)
+		}
+	}
+	return nil
+}
+
+func (d *structArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	if arr.NullN() > 0 {
+		return decodeRangeByValue(d, arr, from, to, p, stride)
+	}
+	return d.decodeFields(arr.(*array.Struct).Field, from, to, p, stride)
+}
+
+// decodeFields decodes struct fields column by column, column returning the child array of a field index.
+func (d *structArrowDecoder) decodeFields(column func(int) arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	for _, f := range d.fields {
+		if err := f.dec.decodeRange(column(f.index), from, to, unsafe.Pointer(uintptr(p)+f.offset), stride); err != nil {
+			return annotateColumn(err, f.name)
+		}
+	}
+	return nil
+}
+
+type ptrArrowDecoder struct {
+	typ  *runtime.Type
+	elem arrowDecoder
+}
+
+func (d *ptrArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		*(*unsafe.Pointer)(p) = nil
+		return nil
+	}
+	newptr := *(*unsafe.Pointer)(p)
+	if newptr == nil {
+		newptr = unsafe_New(d.typ)
+		*(*unsafe.Pointer)(p) = newptr
+	}
+	return d.elem.decode(arr, i, newptr)
+}
+
+func (d *ptrArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// nullArrowDecoder applies a NullPolicy other than KeepNullPolicy to the
+// nulls decoded into a Go struct field which cannot be nil.
+type nullArrowDecoder struct {
+	typ        *runtime.Type
+	policy     NullPolicy
+	zeroValue  unsafe.Pointer
+	structName string
+	field      string
+	elem       arrowDecoder
+}
+
+func (d *nullArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return d.null(arr, p)
+	}
+	return d.elem.decode(arr, i, p)
+}
+
+func (d *nullArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	if err := d.elem.decodeRange(arr, from, to, p, stride); err != nil {
+		return err
+	}
+	if arr.NullN() == 0 {
+		return nil
+	}
+	for i := from; i < to; i++ {
+		if !arr.IsNull(i) {
+			continue
+		}
+		if err := d.null(arr, unsafe.Pointer(uintptr(p)+uintptr(i-from)*stride)); err != nil {
+			return annotateRow(err, i)
+		}
+	}
+	return nil
+}
+
+func (d *nullArrowDecoder) null(arr arrow.Array, p unsafe.Pointer) error {
+	if d.policy == ErrorNullPolicy {
+		err := errors.ErrArrowNull("", arr.DataType(), runtime.RType2Type(d.typ), -1)
+		err.Struct = d.structName
+		err.Field = d.field
+		return err
+	}
+	typedmemmove(d.typ, p, d.zeroValue)
+	return nil
+}
+
+// anonymousArrowDecoder decodes a field promoted from an embedded struct pointer.
+type anonymousArrowDecoder struct {
+	structType *runtime.Type
+	offset     uintptr
+	elem       arrowDecoder
+}
+
+func (d *anonymousArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if *(*unsafe.Pointer)(p) == nil {
+		*(*unsafe.Pointer)(p) = unsafe_New(d.structType)
+	}
+	p = *(*unsafe.Pointer)(p)
+	return d.elem.decode(arr, i, unsafe.Pointer(uintptr(p)+d.offset))
+}
+
+func (d *anonymousArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+type sliceArrowDecoder struct {
+	elemType *runtime.Type
+	size     uintptr
+	elem     arrowDecoder
+}
+
+func (d *sliceArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	dst := (*sliceHeader)(p)
+	if arr.IsNull(i) {
+		dst.data = newArray(d.elemType, 0)
+		dst.len, dst.cap = 0, 0
+		return nil
+	}
+	values, start, end, _ := listValueRange(arr, i)
+	sz := end - start
+	dst.data = newArray(d.elemType, sz)
+	dst.len = sz
+	dst.cap = sz
+	if err := d.elem.decodeRange(values, start, end, dst.data, d.size); err != nil {
+		return annotateElement(err)
+	}
+	return nil
+}
+
+func (d *sliceArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+type arrayArrowDecoder struct {
+	typ       *runtime.Type
+	elemType  *runtime.Type
+	size      uintptr
+	alen      int
+	elem      arrowDecoder
+	zeroValue unsafe.Pointer
+}
+
+func (d *arrayArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		for idx := 0; idx < d.alen; idx++ {
+			typedmemmove(d.elemType, unsafe.Pointer(uintptr(p)+uintptr(idx)*d.size), d.zeroValue)
+		}
+		return nil
+	}
+	values, start, end, _ := listValueRange(arr, i)
+	if end-start != d.alen {
+		return errArrowType("", arr.DataType(), d.typ)
+	}
+	if err := d.elem.decodeRange(values, start, end, p, d.size); err != nil {
+		return annotateElement(err)
+	}
+	return nil
+}
+
+func (d *arrayArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+type boolArrowDecoder struct{}
+
+func (d *boolArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsValid(i) {
+		*(*bool)(p) = arr.(*array.Boolean).Value(i)
+	}
+	return nil
+}
+
+func (d *boolArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	a := arr.(*array.Boolean)
+	for i := from; i < to; i++ {
+		if a.IsValid(i) {
+			*(*bool)(unsafe.Pointer(uintptr(p) + uintptr(i-from)*stride)) = a.Value(i)
+		}
+	}
+	return nil
+}
+
+type stringArray interface {
+	arrow.Array
+	Value(int) string
+}
+
+// stringArrowDecoder decodes string columns into strings copied from the
+// arrow buffer, or aliasing it when alias is set.
+type stringArrowDecoder[A stringArray] struct {
+	alias bool
+}
+
+func (d *stringArrowDecoder[A]) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsValid(i) {
+		v := arr.(A).Value(i)
+		if !d.alias {
+			v = cloneString(v)
+		}
+		*(*string)(p) = v
+	}
+	return nil
+}
+
+func (d *stringArrowDecoder[A]) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	a := arr.(A)
+	if d.alias {
+		for i := from; i < to; i++ {
+			if a.IsValid(i) {
+				*(*string)(unsafe.Pointer(uintptr(p) + uintptr(i-from)*stride)) = a.Value(i)
+			}
+		}
+		return nil
+	}
+
+	// copy the values of the range at once, the strings sharing the copy
+	size := 0
+	for i := from; i < to; i++ {
+		if a.IsValid(i) {
+			size += len(a.Value(i))
+		}
+	}
+	buf := make([]byte, 0, size)
+	for i := from; i < to; i++ {
+		if !a.IsValid(i) {
+			continue
+		}
+		start := len(buf)
+		buf = append(buf, a.Value(i)...)
+		v := buf[start:len(buf):len(buf)]
+		*(*string)(unsafe.Pointer(uintptr(p) + uintptr(i-from)*stride)) = *(*string)(unsafe.Pointer(&v))
+	}
+	return nil
+}
+
+type arrowNumber interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+type goNumber interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr | ~float32 | ~float64
+}
+
+// numberArrowDecoder converts a fixed width arrow value S into a Go number D,
+// reading the value buffer of the array directly.
+type numberArrowDecoder[S arrowNumber, D goNumber] struct{}
+
+func (d *numberArrowDecoder[S, D]) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsValid(i) {
+		*(*D)(p) = D(numberValues[S](arr)[i])
+	}
+	return nil
+}
+
+func (d *numberArrowDecoder[S, D]) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	values := numberValues[S](arr)
+	if arr.NullN() == 0 {
+		if src, ok := any(values).([]D); ok && stride == unsafe.Sizeof(src[0]) {
+			// same representation on both sides, copy the buffer in bulk
+			copy(unsafe.Slice((*D)(p), to-from), src[from:to])
+			return nil
+		}
+		for i := from; i < to; i++ {
+			*(*D)(unsafe.Pointer(uintptr(p) + uintptr(i-from)*stride)) = D(values[i])
+		}
+		return nil
+	}
+	for i := from; i < to; i++ {
+		if arr.IsValid(i) {
+			*(*D)(unsafe.Pointer(uintptr(p) + uintptr(i-from)*stride)) = D(values[i])
+		}
+	}
+	return nil
+}
+
+// numberValues returns the value buffer of a fixed width array, indexed like the array.
+func numberValues[S arrowNumber](arr arrow.Array) []S {
+	data := arr.Data()
+	buf := data.Buffers()[1]
+	if buf == nil || buf.Len() == 0 {
+		return nil
+	}
+	b := buf.Bytes()
+	var zero S
+	values := unsafe.Slice((*S)(unsafe.Pointer(&b[0])), len(b)/int(unsafe.Sizeof(zero)))
+	return values[data.Offset() : data.Offset()+data.Len()]
+}
+
+// durationArrowDecoder decodes a duration or time of day stored as S counts
+// of unit into time.Duration.
+type durationArrowDecoder[S int32 | int64] struct {
+	unit time.Duration
+}
+
+func (d *durationArrowDecoder[S]) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsValid(i) {
+		*(*time.Duration)(p) = time.Duration(numberValues[S](arr)[i]) * d.unit
+	}
+	return nil
+}
+
+func (d *durationArrowDecoder[S]) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	values := numberValues[S](arr)
+	for i := from; i < to; i++ {
+		if arr.IsValid(i) {
+			*(*time.Duration)(unsafe.Pointer(uintptr(p) + uintptr(i-from)*stride)) = time.Duration(values[i]) * d.unit
+		}
+	}
+	return nil
+}
+
+type intervalArrowDecoder struct{}
+
+func (d *intervalArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsValid(i) {
+		*(*arrow.MonthDayNanoInterval)(p) = arr.(*array.MonthDayNanoInterval).Value(i)
+	}
+	return nil
+}
+
+func (d *intervalArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// timestampArrowDecoder decodes timestamps into time.Time in a location
+// resolved when the plan was compiled.
+type timestampArrowDecoder struct {
+	unit arrow.TimeUnit
+	loc  *time.Location
+}
+
+func (d *timestampArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsValid(i) {
+		*(*time.Time)(p) = arrow.Timestamp(numberValues[int64](arr)[i]).ToTime(d.unit).In(d.loc)
+	}
+	return nil
+}
+
+func (d *timestampArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	values := numberValues[int64](arr)
+	for i := from; i < to; i++ {
+		if arr.IsValid(i) {
+			*(*time.Time)(unsafe.Pointer(uintptr(p) + uintptr(i-from)*stride)) = arrow.Timestamp(values[i]).ToTime(d.unit).In(d.loc)
+		}
+	}
+	return nil
+}
+
+// mapArrowDecoder decodes struct children into map values keyed by their
+// name when fields is set, arrow map entries otherwise.
+type mapArrowDecoder struct {
+	mapDec *mapDecoder
+	fields []structArrowField
+	key    arrowDecoder
+	value  arrowDecoder
+}
+
+func (d *mapArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		**(**unsafe.Pointer)(unsafe.Pointer(&p)) = nil
+		return nil
+	}
+	mapValue := *(*unsafe.Pointer)(p)
+	if mapValue == nil {
+		mapValue = makemap(d.mapDec.mapType, 0)
+	}
+	if d.key == nil {
+		st := arr.(*array.Struct)
+		for _, f := range d.fields {
+			k := unsafe_New(d.mapDec.keyType)
+			*(*string)(k) = f.name
+			v := unsafe_New(d.mapDec.valueType)
+			if err := f.dec.decode(st.Field(f.index), i, v); err != nil {
+				return annotateColumn(err, f.name)
+			}
+			d.mapDec.mapassign(d.mapDec.mapType, mapValue, k, v)
+		}
+	} else {
+		m := arr.(*array.Map)
+		start, end := m.ValueOffsets(i)
+		keys, items := m.Keys(), m.Items()
+		for idx := int(start); idx < int(end); idx++ {
+			k := unsafe_New(d.mapDec.keyType)
+			if err := d.key.decode(keys, idx, k); err != nil {
+				return annotateElement(err)
+			}
+			v := unsafe_New(d.mapDec.valueType)
+			if err := d.value.decode(items, idx, v); err != nil {
+				return annotateElement(err)
+			}
+			d.mapDec.mapassign(d.mapDec.mapType, mapValue, k, v)
+		}
+	}
+	*(*unsafe.Pointer)(p) = mapValue
+	return nil
+}
+
+func (d *mapArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// interfaceArrowDecoder decodes into an empty interface, timestamps in the
+// location selected when the plan was compiled.
+type interfaceArrowDecoder struct {
+	dec *interfaceDecoder
+	loc TimeLocation
+}
+
+func (d *interfaceArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.dec.decodeArrayIn(arr, i, p, d.loc)
+}
+
+func (d *interfaceArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
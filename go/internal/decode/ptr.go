@@ -42,6 +42,10 @@ func UnsafeNew(t *runtime.Type) unsafe.Pointer {
 }
 
 func (d *ptrDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.decodeArrayIn(arr, i, p, ColumnTimeLocation)
+}
+
+func (d *ptrDecoder) decodeArrayIn(arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
 	if arr.IsNull(i) {
 		*(*unsafe.Pointer)(p) = nil
 		return nil
@@ -54,7 +58,7 @@ func (d *ptrDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error
 	} else {
 		newptr = *(*unsafe.Pointer)(p)
 	}
-	if err := d.dec.DecodeArray(arr, i, newptr); err != nil {
+	if err := decodeArrayWith(d.dec, arr, i, newptr, loc); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,126 @@
+package decode
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+)
+
+// Row is a view of one row of a record. Its accessors read the record
+// buffers directly, so a Row is only valid while the record is retained.
+type Row struct {
+	record arrow.Record
+	index  int
+}
+
+// Rows returns a view of every row of record.
+func Rows(record arrow.Record) []Row {
+	rows := make([]Row, record.NumRows())
+	for i := range rows {
+		rows[i] = Row{record: record, index: i}
+	}
+	return rows
+}
+
+// Get returns the value at path as decoded by ArrowValue. The path is a dot
+// separated list of column and struct field names, each optionally followed
+// by list indices, as in "spans[0].name". ok is false when the path does not
+// exist in the row.
+func (r Row) Get(path string) (interface{}, bool) {
+	arr, i, ok := r.lookup(path)
+	if !ok {
+		return nil, false
+	}
+	v, err := ArrowValue(arr, i)
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// String returns the string or binary value at path.
+func (r Row) String(path string) (string, bool) {
+	arr, i, ok := r.lookup(path)
+	if !ok || arr.IsNull(i) {
+		return "", false
+	}
+	return stringValue(arr, i)
+}
+
+// Int64 returns the integer value at path widened to int64.
+func (r Row) Int64(path string) (int64, bool) {
+	arr, i, ok := r.lookup(path)
+	if !ok || arr.IsNull(i) {
+		return 0, false
+	}
+	return int64Value(arr, i)
+}
+
+// Time returns the timestamp or date value at path. ok is false for
+// timestamps of an invalid time zone.
+func (r Row) Time(path string) (time.Time, bool) {
+	arr, i, ok := r.lookup(path)
+	if !ok || arr.IsNull(i) {
+		return time.Time{}, false
+	}
+	t, ok, err := timeValue(arr, i, ColumnTimeLocation)
+	return t, ok && err == nil
+}
+
+// lookup resolves path to the array holding its value and the value index.
+func (r Row) lookup(path string) (arrow.Array, int, bool) {
+	if r.record == nil {
+		return nil, 0, false
+	}
+	var (
+		arr arrow.Array
+		i   = r.index
+	)
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		if idx := strings.IndexByte(segment, '['); idx >= 0 {
+			name, segment = segment[:idx], segment[idx:]
+		} else {
+			segment = ""
+		}
+
+		if arr == nil {
+			indices := r.record.Schema().FieldIndices(name)
+			if len(indices) == 0 {
+				return nil, 0, false
+			}
+			arr = r.record.Column(indices[0])
+		} else {
+			st, ok := arr.(*array.Struct)
+			if !ok || st.IsNull(i) {
+				return nil, 0, false
+			}
+			idx, ok := st.DataType().(*arrow.StructType).FieldIdx(name)
+			if !ok {
+				return nil, 0, false
+			}
+			arr = st.Field(idx)
+		}
+
+		for segment != "" {
+			end := strings.IndexByte(segment, ']')
+			if segment[0] != '[' || end < 0 {
+				return nil, 0, false
+			}
+			n, err := strconv.Atoi(segment[1:end])
+			if err != nil || arr.IsNull(i) {
+				return nil, 0, false
+			}
+			values, start, stop, ok := listValueRange(arr, i)
+			if !ok || n < 0 || start+n >= stop {
+				return nil, 0, false
+			}
+			arr, i = values, start+n
+			segment = segment[end+1:]
+		}
+	}
+	return arr, i, arr != nil
+}
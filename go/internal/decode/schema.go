@@ -0,0 +1,573 @@
+package decode
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+	fbs "github.com/chronowave/fbs/go"
+)
+
+var (
+	timeType     = runtime.Type2RType(reflect.TypeOf(time.Time{}))
+	durationType = runtime.Type2RType(reflect.TypeOf(time.Duration(0)))
+)
+
+// compileArrowDecoder binds dec to the arrow type dt of a record column,
+// resolving struct children, list layouts and numeric accessors once per
+// record schema. It fails when dec is unable to decode values of dt into a
+// Go value of type typ, so no row is decoded against a mismatching layout.
+func compileArrowDecoder(typ *runtime.Type, dec Decoder, dt arrow.DataType, column, layout string, opt *Option) (arrowDecoder, error) {
+	switch dec.(type) {
+	case *unmarshalArrowDecoder, *extensionDecoder, *ptrDecoder:
+		// they check the type of extension columns themselves, or leave it
+		// to the decoder of their element
+	default:
+		if ext, ok := dt.(arrow.ExtensionType); ok {
+			elem, err := compileArrowDecoder(typ, dec, ext.StorageType(), column, layout, opt)
+			if err != nil {
+				return nil, err
+			}
+			return &extensionArrowDecoder{elem: elem}, nil
+		}
+		if d, ok := compileBinaryArrowDecoder(typ, dt); ok {
+			return d, nil
+		}
+	}
+	if layout == JSONLayout && isStringType(dt) {
+		switch typ.Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			return &jsonTextArrowDecoder{typ: typ, dec: dec}, nil
+		}
+	}
+	switch d := dec.(type) {
+	case *ptrDecoder:
+		elem, err := compileArrowDecoder(d.typ, d.dec, dt, column, layout, opt)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := dt.(arrow.UnionType); ok {
+			return &unionPtrArrowDecoder{typ: d.typ, elem: elem}, nil
+		}
+		return &ptrArrowDecoder{typ: d.typ, elem: elem}, nil
+	case *anonymousFieldDecoder:
+		elem, err := compileArrowDecoder(typ, d.dec, dt, column, layout, opt)
+		if err != nil {
+			return nil, err
+		}
+		return &anonymousArrowDecoder{structType: d.structType, offset: d.offset, elem: elem}, nil
+	case *wrappedStringDecoder:
+		if isStringType(dt) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+		if _, err := compileArrowDecoder(typ, d.dec, dt, column, layout, opt); err != nil {
+			return nil, err
+		}
+		return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+	case *structDecoder:
+		if ut, ok := dt.(arrow.UnionType); ok {
+			return compileOneofArrowDecoder(typ, d, ut, column, opt)
+		}
+		return compileStructArrowDecoder(typ, d, dt, column, opt)
+	case *intervalDecoder:
+		if dt.ID() == arrow.INTERVAL_MONTH_DAY_NANO {
+			return &intervalArrowDecoder{}, nil
+		}
+	case *sliceDecoder:
+		elemType, ok := listElemType(dt)
+		if !ok {
+			return nil, errArrowType(column, dt, typ)
+		}
+		elem, err := compileArrowDecoder(d.elemType, d.valueDecoder, elemType, column+"[]", ElemLayout(dt, layout), opt)
+		if err != nil {
+			return nil, err
+		}
+		return &sliceArrowDecoder{elemType: d.elemType, size: d.size, elem: elem}, nil
+	case *arrayDecoder:
+		elemType, ok := listElemType(dt)
+		if !ok {
+			return nil, errArrowType(column, dt, typ)
+		}
+		if fsl, ok := dt.(*arrow.FixedSizeListType); ok && int(fsl.Len()) != d.alen {
+			return nil, errArrowType(column, dt, typ)
+		}
+		elem, err := compileArrowDecoder(d.elemType, d.valueDecoder, elemType, column+"[]", ElemLayout(dt, layout), opt)
+		if err != nil {
+			return nil, err
+		}
+		return &arrayArrowDecoder{
+			typ:       typ,
+			elemType:  d.elemType,
+			size:      d.size,
+			alen:      d.alen,
+			elem:      elem,
+			zeroValue: d.zeroValue,
+		}, nil
+	case *bytesDecoder:
+		if isStringType(dt) {
+			if opt.Flags&ZeroCopyOption != 0 {
+				return &aliasBytesArrowDecoder{typ: typ}, nil
+			}
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+		if _, ok := listElemType(dt); ok {
+			return compileArrowDecoder(typ, d.sliceDecoder, dt, column, layout, opt)
+		}
+	case *stringDecoder:
+		switch dt.ID() {
+		case arrow.STRING:
+			return &stringArrowDecoder[*array.String]{alias: opt.Flags&ZeroCopyOption != 0}, nil
+		case arrow.LARGE_STRING:
+			return &stringArrowDecoder[*array.LargeString]{alias: opt.Flags&ZeroCopyOption != 0}, nil
+		}
+		if isStringType(dt) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+	case *boolDecoder:
+		if dt.ID() == arrow.BOOL {
+			return &boolArrowDecoder{}, nil
+		}
+	case *intDecoder:
+		if unit, ok := durationUnit(dt); ok && typ == durationType {
+			return compileDurationArrowDecoder(dt, unit), nil
+		}
+		if bits, signed, ok := integerWidth(dt); ok {
+			size := int(typ.Size()) * 8
+			if (signed && bits <= size) || (!signed && bits < size) {
+				return compileNumberArrowDecoder(dt, typ.Kind()), nil
+			}
+		}
+	case *uintDecoder:
+		if bits, _, ok := integerWidth(dt); ok && bits <= int(typ.Size())*8 {
+			return compileNumberArrowDecoder(dt, typ.Kind()), nil
+		}
+	case *floatDecoder:
+		if _, _, ok := integerWidth(dt); ok {
+			return compileNumberArrowDecoder(dt, typ.Kind()), nil
+		}
+		switch dt.ID() {
+		case arrow.FLOAT32, arrow.FLOAT64:
+			return compileNumberArrowDecoder(dt, typ.Kind()), nil
+		}
+	case *numberDecoder:
+		if _, _, ok := integerWidth(dt); ok || isStringType(dt) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+		switch dt.ID() {
+		case arrow.FLOAT32, arrow.FLOAT64:
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+	case *unmarshalJSONDecoder:
+		if isDecimalType(dt) && isDecimalGoType(d.typ) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+		if ts, ok := dt.(*arrow.TimestampType); ok && d.typ.Elem() == timeType {
+			loc, err := timeLocation(opt.TimeLocation, ts.TimeZone)
+			if err != nil {
+				return nil, errTimeZone(column, ts.TimeZone, err)
+			}
+			return &timestampArrowDecoder{unit: ts.Unit, loc: loc}, nil
+		}
+		if d.typ.Elem() == timeType {
+			if _, _, ok := integerWidth(dt); ok || isStringType(dt) {
+				dec := &layoutTimeArrowDecoder{layout: NewTimeLayout(layout)}
+				if opt.TimeLocation != ColumnTimeLocation {
+					dec.loc, _ = timeLocation(opt.TimeLocation, "")
+				}
+				return dec, nil
+			}
+			switch dt.ID() {
+			case arrow.TIMESTAMP, arrow.DATE32, arrow.DATE64, arrow.TIME32, arrow.TIME64:
+				return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+			}
+		}
+		if isStringType(dt) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+	case *unmarshalTextDecoder:
+		if isStringType(dt) || isDecimalType(dt) && isDecimalGoType(d.typ) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+	case *unmarshalArrowDecoder:
+		if want := arrowType(d.typ); want != nil && !arrow.TypeEqual(dt, want) {
+			return nil, errArrowType(column, dt, typ)
+		}
+		return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+	case *extensionDecoder:
+		if d.ext.Accepts(dt) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+		return nil, errArrowType(column, dt, typ)
+	case *unmarshalDecimalDecoder:
+		if isDecimalType(dt) {
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+	case *mapDecoder:
+		return compileMapArrowDecoder(typ, d, dt, column, layout, opt)
+	case *interfaceDecoder:
+		if d.typ != emptyInterfaceType {
+			if ut, ok := dt.(arrow.UnionType); ok {
+				if variants := LookupUnion(runtime.RType2Type(d.typ)); variants != nil {
+					return compileUnionArrowDecoder(d.typ, ut, variants, column, opt)
+				}
+			}
+			return &dynamicArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+		if isArrowValueType(dt) {
+			if err := validateTimeZones(dt, column); err != nil {
+				return nil, err
+			}
+			return &interfaceArrowDecoder{dec: d, loc: opt.TimeLocation}, nil
+		}
+	}
+	return nil, errArrowType(column, dt, typ)
+}
+
+func compileStructArrowDecoder(typ *runtime.Type, d *structDecoder, dt arrow.DataType, column string, opt *Option) (*structArrowDecoder, error) {
+	st, ok := dt.(*arrow.StructType)
+	if !ok {
+		return nil, errArrowType(column, dt, typ)
+	}
+	dec := &structArrowDecoder{}
+	matched := make(map[*structFieldSet]bool, len(st.Fields()))
+	for i, field := range matchColumns(d, st.Fields()) {
+		f := st.Field(i)
+		if field == nil {
+			if opt.Flags&DisallowUnknownColumnsOption != 0 {
+				return nil, errors.ErrUnknownColumn(joinColumn(column, f.Name), typ.Name())
+			}
+			continue
+		}
+		matched[field] = true
+		fieldDec, err := compileArrowDecoder(field.typ, field.dec, f.Type, joinColumn(column, f.Name), FieldLayout(f, ""), opt)
+		if err != nil {
+			if e, ok := err.(*errors.UnmarshalTypeError); ok && e.Struct == "" {
+				e.Struct = typ.Name()
+				e.Field = field.key
+			}
+			return nil, err
+		}
+		dec.fields = append(dec.fields, structArrowField{
+			index:  i,
+			offset: field.offset,
+			name:   f.Name,
+			dec:    withNullPolicy(fieldDec, field.typ, typ.Name(), field.key, opt),
+		})
+	}
+	if err := checkMissingColumns(d, matched, typ, column, opt); err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// checkMissingColumns fails when opt requires all fields and a field of the
+// struct decoder d has no matched column.
+func checkMissingColumns(d *structDecoder, matched map[*structFieldSet]bool, typ *runtime.Type, column string, opt *Option) error {
+	if opt.Flags&RequireAllFieldsOption == 0 {
+		return nil
+	}
+	for _, field := range d.fieldSets {
+		if !matched[field] {
+			return errors.ErrMissingColumn(joinColumn(column, field.key), typ.Name(), field.key)
+		}
+	}
+	return nil
+}
+
+// matchColumns returns the field of d decoding each of columns, nil for the
+// columns matching none. A column matches the field of its name, else the
+// field with an alias of its name, else case-insensitively as with
+// encoding/json. Each field decodes a single column, so that a field named
+// after one column is not overwritten by another column matching it by alias
+// or case.
+func matchColumns(d *structDecoder, columns []arrow.Field) []*structFieldSet {
+	matches := make([]*structFieldSet, len(columns))
+	taken := make(map[*structFieldSet]bool, len(columns))
+	for _, match := range []func(f *structFieldSet, name string) bool{
+		func(f *structFieldSet, name string) bool { return f.key == name },
+		func(f *structFieldSet, name string) bool { return containsName(f.aliases, name, false) },
+		func(f *structFieldSet, name string) bool { return strings.EqualFold(f.key, name) },
+		func(f *structFieldSet, name string) bool { return containsName(f.aliases, name, true) },
+	} {
+		for i, c := range columns {
+			if matches[i] != nil {
+				continue
+			}
+			for _, f := range d.fieldSets {
+				if !taken[f] && match(f, c.Name) {
+					matches[i] = f
+					taken[f] = true
+					break
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// MatchedColumns returns the names of the columns the fields of the struct typ
+// decode from, matched with the fields as when decoding them.
+func MatchedColumns(typ reflect.Type, columns []arrow.Field) ([]string, error) {
+	dec, err := CompileToGetDecoder(runtime.Type2RType(reflect.PointerTo(typ)))
+	if err != nil {
+		return nil, err
+	}
+	structDec, ok := dec.(*structDecoder)
+	if !ok {
+		return nil, nil
+	}
+	var names []string
+	for i, field := range matchColumns(structDec, columns) {
+		if field != nil {
+			names = append(names, columns[i].Name)
+		}
+	}
+	return names, nil
+}
+
+func containsName(names []string, name string, fold bool) bool {
+	for _, n := range names {
+		if n == name || fold && strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// withNullPolicy applies the null policy of opt to dec, decoding a Go struct
+// field of type typ, unless the field can be nil.
+func withNullPolicy(dec arrowDecoder, typ *runtime.Type, structName, field string, opt *Option) arrowDecoder {
+	if opt.NullPolicy == KeepNullPolicy {
+		return dec
+	}
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return dec
+	}
+	if anonymous, ok := dec.(*anonymousArrowDecoder); ok {
+		// the policy applies to the promoted field, not the embedded pointer
+		anonymous.elem = withNullPolicy(anonymous.elem, typ, structName, field, opt)
+		return anonymous
+	}
+	return &nullArrowDecoder{
+		typ:        typ,
+		policy:     opt.NullPolicy,
+		zeroValue:  unsafe_New(typ),
+		structName: structName,
+		field:      field,
+		elem:       dec,
+	}
+}
+
+// compileMapArrowDecoder binds a map either to the children of a struct keyed
+// by column name, or to the entries of an arrow map.
+func compileMapArrowDecoder(typ *runtime.Type, d *mapDecoder, dt arrow.DataType, column, layout string, opt *Option) (arrowDecoder, error) {
+	switch t := dt.(type) {
+	case *arrow.StructType:
+		if d.keyType.Kind() != reflect.String {
+			break
+		}
+		dec := &mapArrowDecoder{mapDec: d}
+		for i, f := range t.Fields() {
+			valueDec, err := compileArrowDecoder(d.valueType, d.valueDecoder, f.Type, joinColumn(column, f.Name), FieldLayout(f, ""), opt)
+			if err != nil {
+				return nil, err
+			}
+			dec.fields = append(dec.fields, structArrowField{index: i, name: f.Name, dec: valueDec})
+		}
+		return dec, nil
+	case *arrow.MapType:
+		keyDec, err := compileArrowDecoder(d.keyType, d.keyDecoder, t.KeyType(), column+"[]", layout, opt)
+		if err != nil {
+			return nil, err
+		}
+		valueDec, err := compileArrowDecoder(d.valueType, d.valueDecoder, t.ItemType(), column+"[]", layout, opt)
+		if err != nil {
+			return nil, err
+		}
+		return &mapArrowDecoder{mapDec: d, key: keyDec, value: valueDec}, nil
+	}
+	return nil, errArrowType(column, dt, typ)
+}
+
+// validateTimeZones checks the time zone of every timestamp in dt.
+func validateTimeZones(dt arrow.DataType, column string) error {
+	switch t := dt.(type) {
+	case *arrow.TimestampType:
+		if _, err := loadLocation(t.TimeZone); err != nil {
+			return errTimeZone(column, t.TimeZone, err)
+		}
+	case *arrow.StructType:
+		for _, f := range t.Fields() {
+			if err := validateTimeZones(f.Type, joinColumn(column, f.Name)); err != nil {
+				return err
+			}
+		}
+	case *arrow.MapType:
+		return validateTimeZones(t.ItemType(), column+"[]")
+	default:
+		if elem, ok := listElemType(dt); ok {
+			return validateTimeZones(elem, column+"[]")
+		}
+	}
+	return nil
+}
+
+func errTimeZone(column, tz string, err error) error {
+	if column == "" {
+		return fmt.Errorf("arrow: invalid time zone %q: %w", tz, err)
+	}
+	return fmt.Errorf("arrow: column %s has invalid time zone %q: %w", column, tz, err)
+}
+
+func compileDurationArrowDecoder(dt arrow.DataType, unit time.Duration) arrowDecoder {
+	if dt.ID() == arrow.TIME32 {
+		return &durationArrowDecoder[int32]{unit: unit}
+	}
+	return &durationArrowDecoder[int64]{unit: unit}
+}
+
+// FieldLayout returns the time layout recorded in the metadata of f,
+// JSONLayout when it holds JSON text, or def when there is none.
+func FieldLayout(f arrow.Field, def string) string {
+	if idx := f.Metadata.FindKey(EncodingMetadataKey); idx >= 0 && f.Metadata.Values()[idx] == JSONEncoding {
+		return JSONLayout
+	}
+	if idx := f.Metadata.FindKey(fbs.EnumNamesMetadataKey[fbs.MetadataKeyLAYOUT]); idx >= 0 {
+		return f.Metadata.Values()[idx]
+	}
+	return def
+}
+
+// ElemLayout returns the time layout of the elements of the list type dt,
+// DeriveArrowSchema recording the layout of list elements on the list field.
+func ElemLayout(dt arrow.DataType, layout string) string {
+	switch t := dt.(type) {
+	case *arrow.ListType:
+		return FieldLayout(t.ElemField(), layout)
+	case *arrow.LargeListType:
+		return FieldLayout(t.ElemField(), layout)
+	case *arrow.FixedSizeListType:
+		return FieldLayout(t.ElemField(), layout)
+	}
+	return layout
+}
+
+// compileNumberArrowDecoder returns the decoder converting the fixed width
+// arrow type dt into a Go number of the given kind.
+func compileNumberArrowDecoder(dt arrow.DataType, kind reflect.Kind) arrowDecoder {
+	switch dt.ID() {
+	case arrow.INT8:
+		return numberArrowDecoderOf[int8](kind)
+	case arrow.INT16:
+		return numberArrowDecoderOf[int16](kind)
+	case arrow.INT32:
+		return numberArrowDecoderOf[int32](kind)
+	case arrow.INT64:
+		return numberArrowDecoderOf[int64](kind)
+	case arrow.UINT8:
+		return numberArrowDecoderOf[uint8](kind)
+	case arrow.UINT16:
+		return numberArrowDecoderOf[uint16](kind)
+	case arrow.UINT32:
+		return numberArrowDecoderOf[uint32](kind)
+	case arrow.UINT64:
+		return numberArrowDecoderOf[uint64](kind)
+	case arrow.FLOAT32:
+		return numberArrowDecoderOf[float32](kind)
+	case arrow.FLOAT64:
+		return numberArrowDecoderOf[float64](kind)
+	}
+	return nil
+}
+
+func numberArrowDecoderOf[S arrowNumber](kind reflect.Kind) arrowDecoder {
+	switch kind {
+	case reflect.Int:
+		return &numberArrowDecoder[S, int]{}
+	case reflect.Int8:
+		return &numberArrowDecoder[S, int8]{}
+	case reflect.Int16:
+		return &numberArrowDecoder[S, int16]{}
+	case reflect.Int32:
+		return &numberArrowDecoder[S, int32]{}
+	case reflect.Int64:
+		return &numberArrowDecoder[S, int64]{}
+	case reflect.Uint:
+		return &numberArrowDecoder[S, uint]{}
+	case reflect.Uint8:
+		return &numberArrowDecoder[S, uint8]{}
+	case reflect.Uint16:
+		return &numberArrowDecoder[S, uint16]{}
+	case reflect.Uint32:
+		return &numberArrowDecoder[S, uint32]{}
+	case reflect.Uint64:
+		return &numberArrowDecoder[S, uint64]{}
+	case reflect.Uintptr:
+		return &numberArrowDecoder[S, uintptr]{}
+	case reflect.Float32:
+		return &numberArrowDecoder[S, float32]{}
+	case reflect.Float64:
+		return &numberArrowDecoder[S, float64]{}
+	}
+	return nil
+}
+
+func errArrowType(column string, dt arrow.DataType, typ *runtime.Type) *errors.UnmarshalTypeError {
+	return errors.ErrArrowType(column, dt, runtime.RType2Type(typ), -1)
+}
+
+func joinColumn(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func isStringType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.STRING, arrow.LARGE_STRING, arrow.BINARY, arrow.LARGE_BINARY, arrow.FIXED_SIZE_BINARY:
+		return true
+	}
+	return false
+}
+
+func integerWidth(dt arrow.DataType) (int, bool, bool) {
+	switch dt.ID() {
+	case arrow.INT8:
+		return 8, true, true
+	case arrow.INT16:
+		return 16, true, true
+	case arrow.INT32:
+		return 32, true, true
+	case arrow.INT64:
+		return 64, true, true
+	case arrow.UINT8:
+		return 8, false, true
+	case arrow.UINT16:
+		return 16, false, true
+	case arrow.UINT32:
+		return 32, false, true
+	case arrow.UINT64:
+		return 64, false, true
+	}
+	return 0, false, false
+}
+
+func listElemType(dt arrow.DataType) (arrow.DataType, bool) {
+	switch t := dt.(type) {
+	case *arrow.ListType:
+		return t.Elem(), true
+	case *arrow.LargeListType:
+		return t.Elem(), true
+	case *arrow.FixedSizeListType:
+		return t.Elem(), true
+	}
+	return nil, false
+}
@@ -6,7 +6,6 @@ import (
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -102,42 +101,31 @@ func (d *sliceDecoder) errNumber(offset int64) *errors.UnmarshalTypeError {
 	}
 }
 
-func (d *sliceDecoder) DecodeStructArray(arr *array.Struct, p unsafe.Pointer) error {
-	dst := (*sliceHeader)(p)
-	dst.data = newArray(d.elemType, arr.Len())
-	dst.len = arr.Len()
-	dst.cap = arr.Len()
-
-	for idx := 0; idx < arr.Len(); idx++ {
-		ep := unsafe.Pointer(uintptr(dst.data) + uintptr(idx)*d.size)
-		if err := d.valueDecoder.DecodeArray(arr, idx, ep); err != nil {
-			return err
-		}
-	}
-
-	return nil
+func (d *sliceDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.decodeArrayIn(arr, i, p, ColumnTimeLocation)
 }
 
-func (d *sliceDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+func (d *sliceDecoder) decodeArrayIn(arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
 	dst := (*sliceHeader)(p)
 	if arr.IsNull(i) {
 		dst.data = newArray(d.elemType, 0)
 		dst.len, dst.cap = 0, 0
 		return nil
 	}
-	list := arr.(*array.List)
-	start, end := list.ValueOffsets(i)
-	sz := int(end - start)
+	values, start, end, ok := listValueRange(arr, i)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), reflect.SliceOf(runtime.RType2Type(d.elemType)), -1)
+	}
+	sz := end - start
 	dst.data = newArray(d.elemType, sz)
 	dst.len = sz
 	dst.cap = sz
 
 	data := dst.data
-	values := list.ListValues()
 	for idx := 0; idx < sz; idx++ {
 		ep := unsafe.Pointer(uintptr(data) + uintptr(idx)*d.size)
-		if err := d.valueDecoder.DecodeArray(values, int(start)+idx, ep); err != nil {
-			return err
+		if err := decodeArrayWith(d.valueDecoder, values, start+idx, ep, loc); err != nil {
+			return annotateColumn(err, "[]")
 		}
 	}
 	return nil
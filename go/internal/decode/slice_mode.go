@@ -0,0 +1,72 @@
+package decode
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+// sliceRows prepares the slices of Go values decoded from record rows.
+type sliceRows struct {
+	elemType    *runtime.Type
+	size        uintptr
+	zeroValue   unsafe.Pointer
+	pointee     *runtime.Type // type pointed to by pointer elements
+	pointeeZero unsafe.Pointer
+}
+
+func newSliceRows(elemType *runtime.Type, size uintptr) sliceRows {
+	r := sliceRows{elemType: elemType, size: size, zeroValue: unsafe_New(elemType)}
+	if elemType.Kind() == reflect.Ptr {
+		r.pointee = elemType.Elem()
+		r.pointeeZero = unsafe_New(r.pointee)
+	}
+	return r
+}
+
+// prepare makes room in dst for n rows as selected by mode. It returns the
+// address of the element of the first row, and whether the elements were
+// reused, pointer elements then pointing to reset values unless keep is set.
+// With keep, reused elements hold their values for null columns to keep.
+func (r *sliceRows) prepare(dst *sliceHeader, n int, mode SliceMode, keep bool) (unsafe.Pointer, bool) {
+	switch {
+	case mode == AppendSliceMode && dst.len+n <= dst.cap:
+		data := r.elem(dst.data, dst.len)
+		// the spare capacity may hold values of earlier rows
+		for i := 0; i < n; i++ {
+			typedmemmove(r.elemType, r.elem(data, i), r.zeroValue)
+		}
+		dst.len += n
+		return data, false
+	case mode == AppendSliceMode:
+		capacity := 2 * dst.cap
+		if capacity < dst.len+n {
+			capacity = dst.len + n
+		}
+		grown := sliceHeader{data: newArray(r.elemType, capacity), len: dst.len, cap: capacity}
+		copySlice(r.elemType, grown, *dst)
+		*dst = grown
+		dst.len += n
+		return r.elem(dst.data, grown.len), false
+	case mode == ReuseSliceMode && n <= dst.cap:
+		for i := 0; i < n && !keep; i++ {
+			p := r.elem(dst.data, i)
+			if r.pointee == nil {
+				typedmemmove(r.elemType, p, r.zeroValue)
+			} else if pointee := *(*unsafe.Pointer)(p); pointee != nil {
+				typedmemmove(r.pointee, pointee, r.pointeeZero)
+			}
+		}
+		dst.len = n
+		return dst.data, true
+	}
+	dst.data = newArray(r.elemType, n)
+	dst.len = n
+	dst.cap = n
+	return dst.data, false
+}
+
+func (r *sliceRows) elem(data unsafe.Pointer, i int) unsafe.Pointer {
+	return unsafe.Pointer(uintptr(data) + uintptr(i)*r.size)
+}
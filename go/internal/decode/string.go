@@ -10,7 +10,6 @@ import (
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 )
@@ -38,9 +37,14 @@ func (d *stringDecoder) errUnmarshalType(typeName string, offset int64) *errors.
 }
 
 func (d *stringDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
-	if arr.IsValid(i) {
-		**(**string)(unsafe.Pointer(&p)) = arr.(*array.String).Value(i)
+	if arr.IsNull(i) {
+		return nil
 	}
+	v, ok := stringValue(arr, i)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), reflect.TypeOf(""), -1)
+	}
+	**(**string)(unsafe.Pointer(&p)) = cloneString(v)
 	return nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"math/bits"
+	"reflect"
 	"sort"
 	"strings"
 	"unicode"
@@ -14,20 +15,24 @@ import (
 	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
 )
 
 type structFieldSet struct {
 	dec         Decoder
+	typ         *runtime.Type
 	offset      uintptr
 	isTaggedKey bool
 	fieldIdx    int
 	key         string
 	keyLen      int64
+	aliases     []string // alternate names of arrow columns
 	err         error
 }
 
 type structDecoder struct {
 	fieldMap           map[string]*structFieldSet
+	fieldSets          []*structFieldSet // fields in declaration order
 	fieldUniqueNameNum int
 	stringDecoder      *stringDecoder
 	structName         string
@@ -642,7 +647,10 @@ func decodeKeyStream(d *structDecoder, s *Stream) (*structFieldSet, string, erro
 }
 
 func (d *structDecoder) DecodeArray(s arrow.Array, i int, p unsafe.Pointer) error {
-	arr := s.(*array.Struct)
+	arr, ok := s.(*array.Struct)
+	if !ok {
+		return errors.ErrArrowType("", s.DataType(), reflect.TypeOf(struct{}{}), -1)
+	}
 
 	fieldList := arr.DataType().(*arrow.StructType).Fields()
 	for f := 0; f < arr.NumField(); f++ {
@@ -653,7 +661,7 @@ func (d *structDecoder) DecodeArray(s arrow.Array, i int, p unsafe.Pointer) erro
 
 		na := arr.Field(f)
 		if err := field.dec.DecodeArray(na, i, unsafe.Pointer(uintptr(p)+field.offset)); err != nil {
-			return err
+			return annotateColumn(err, fieldList[f].Name)
 		}
 	}
 	return nil
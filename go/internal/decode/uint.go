@@ -6,7 +6,6 @@ import (
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -135,31 +134,11 @@ func (d *uintDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) erro
 	if arr.IsNull(i) {
 		return nil
 	}
-
-	var u64 uint64
-	switch arr.(type) {
-	case *array.Int32:
-		u64 = uint64(arr.(*array.Int32).Value(i))
-	case *array.Uint32:
-		u64 = uint64(arr.(*array.Uint32).Value(i))
+	v, ok := uint64Value(arr, i)
+	if !ok {
+		return errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.typ), -1)
 	}
-
-	/*
-		switch d.kind {
-		case reflect.Uint8:
-			if ua, ok := arr.(*array.Uint8); ok {
-				u64 = uint64(ua.Value(i))
-			} else
-			ia, ok := arr.(*array.Int8)
-		case reflect.Uint16:
-			u64 = uint64(arr.(*array.Uint16).Value(i))
-		case reflect.Uint32:
-			u64 = uint64(arr.(*array.Uint32).Value(i))
-		default:
-			u64 = arr.(*array.Uint64).Value(i)
-		}
-	*/
-	d.op(p, u64)
+	d.op(p, v)
 	return nil
 }
 
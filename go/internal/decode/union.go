@@ -0,0 +1,235 @@
+package decode
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/errors"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+var unions = struct {
+	sync.RWMutex
+	byType map[reflect.Type]map[arrow.UnionTypeCode]reflect.Type
+}{
+	byType: map[reflect.Type]map[arrow.UnionTypeCode]reflect.Type{},
+}
+
+// RegisterUnion registers the variants of the interface type iface: the Go
+// types values of union columns decode into, by type code. It fails when
+// iface is already registered or a variant does not implement it.
+func RegisterUnion(iface reflect.Type, variants map[arrow.UnionTypeCode]reflect.Type) error {
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("union type %s is not an interface", iface)
+	}
+	copied := make(map[arrow.UnionTypeCode]reflect.Type, len(variants))
+	seen := make(map[reflect.Type]bool, len(variants))
+	for code, t := range variants {
+		if !t.Implements(iface) {
+			return fmt.Errorf("union variant %s does not implement %s", t, iface)
+		}
+		if seen[t] {
+			return fmt.Errorf("union variant %s registered for several type codes", t)
+		}
+		seen[t] = true
+		copied[code] = t
+	}
+	unions.Lock()
+	defer unions.Unlock()
+	if _, ok := unions.byType[iface]; ok {
+		return fmt.Errorf("union %s already registered", iface)
+	}
+	unions.byType[iface] = copied
+	return nil
+}
+
+// LookupUnion returns the variants registered for the interface type iface,
+// nil when there are none.
+func LookupUnion(iface reflect.Type) map[arrow.UnionTypeCode]reflect.Type {
+	unions.RLock()
+	defer unions.RUnlock()
+	return unions.byType[iface]
+}
+
+// unionChild returns the child array holding the i-th value of the union arr,
+// and the index of the value in it.
+func unionChild(arr array.Union, i int) (arrow.Array, int) {
+	child := arr.Field(arr.ChildID(i))
+	if dense, ok := arr.(*array.DenseUnion); ok {
+		return child, int(dense.ValueOffset(i))
+	}
+	return child, i
+}
+
+// isNullUnion reports whether the i-th value of the union arr is null, unions
+// having no validity bitmap of their own.
+func isNullUnion(arr array.Union, i int) bool {
+	child, j := unionChild(arr, i)
+	return child.IsNull(j)
+}
+
+// unionPtrArrowDecoder is ptrArrowDecoder for union columns, setting the
+// pointer to nil for null union values.
+type unionPtrArrowDecoder struct {
+	typ  *runtime.Type
+	elem arrowDecoder
+}
+
+func (d *unionPtrArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if isNullUnion(arr.(array.Union), i) {
+		*(*unsafe.Pointer)(p) = nil
+		return nil
+	}
+	newptr := *(*unsafe.Pointer)(p)
+	if newptr == nil {
+		newptr = unsafe_New(d.typ)
+		*(*unsafe.Pointer)(p) = newptr
+	}
+	return d.elem.decode(arr, i, newptr)
+}
+
+func (d *unionPtrArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// unionVariant decodes the values of a union child into a new value of typ.
+type unionVariant struct {
+	typ  reflect.Type
+	elem arrowDecoder
+}
+
+// unionArrowDecoder decodes union values into an interface type, as the
+// variant registered for their type code. Null values leave the interface
+// unchanged.
+type unionArrowDecoder struct {
+	typ      reflect.Type
+	variants []unionVariant
+	names    []string
+}
+
+// compileUnionArrowDecoder returns the decoder of the union type dt into the
+// interface type typ, each child of dt being decoded into the variant
+// registered for its type code.
+func compileUnionArrowDecoder(typ *runtime.Type, dt arrow.UnionType, variants map[arrow.UnionTypeCode]reflect.Type, column string, opt *Option) (arrowDecoder, error) {
+	dec := &unionArrowDecoder{
+		typ:      runtime.RType2Type(typ),
+		variants: make([]unionVariant, len(dt.Fields())),
+		names:    make([]string, len(dt.Fields())),
+	}
+	for i, f := range dt.Fields() {
+		code := dt.TypeCodes()[i]
+		vt, ok := variants[code]
+		if !ok {
+			return nil, errArrowType(joinColumn(column, f.Name), f.Type, typ)
+		}
+		variantType := runtime.Type2RType(vt)
+		variantDec, err := CompileToGetDecoder(runtime.PtrTo(variantType))
+		if err != nil {
+			return nil, err
+		}
+		elem, err := compileArrowDecoder(variantType, variantDec, f.Type, joinColumn(column, f.Name), FieldLayout(f, ""), opt)
+		if err != nil {
+			return nil, err
+		}
+		dec.variants[i] = unionVariant{typ: vt, elem: elem}
+		dec.names[i] = f.Name
+	}
+	return dec, nil
+}
+
+func (d *unionArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	u := arr.(array.Union)
+	if isNullUnion(u, i) {
+		return nil
+	}
+	id := u.ChildID(i)
+	child, j := unionChild(u, i)
+	variant := d.variants[id]
+	v := reflect.New(variant.typ)
+	if err := variant.elem.decode(child, j, v.UnsafePointer()); err != nil {
+		return annotateColumn(err, d.names[id])
+	}
+	reflect.NewAt(d.typ, p).Elem().Set(v.Elem())
+	return nil
+}
+
+func (d *unionArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
+
+// oneofField is the struct field decoding the values of a union child.
+type oneofField struct {
+	offset uintptr
+	typ    reflect.Type
+	name   string
+	dec    arrowDecoder
+}
+
+// oneofArrowDecoder decodes union values into a struct with a field per
+// variant, such as the structs DeriveArrowSchema maps onto unions with the
+// oneof tag. The field of the type code of a value is set and the others are
+// reset, all of them for null values.
+type oneofArrowDecoder struct {
+	fields []*oneofField
+	all    []*oneofField
+}
+
+// compileOneofArrowDecoder returns the decoder of the union type dt into the
+// struct typ, its children matching fields as columns do.
+func compileOneofArrowDecoder(typ *runtime.Type, d *structDecoder, dt arrow.UnionType, column string, opt *Option) (arrowDecoder, error) {
+	dec := &oneofArrowDecoder{fields: make([]*oneofField, len(dt.Fields()))}
+	for i, field := range matchColumns(d, dt.Fields()) {
+		f := dt.Fields()[i]
+		if field == nil {
+			if opt.Flags&DisallowUnknownColumnsOption != 0 {
+				return nil, errors.ErrUnknownColumn(joinColumn(column, f.Name), typ.Name())
+			}
+			continue
+		}
+		fieldDec, err := compileArrowDecoder(field.typ, field.dec, f.Type, joinColumn(column, f.Name), FieldLayout(f, ""), opt)
+		if err != nil {
+			if e, ok := err.(*errors.UnmarshalTypeError); ok && e.Struct == "" {
+				e.Struct = typ.Name()
+				e.Field = field.key
+			}
+			return nil, err
+		}
+		dec.fields[i] = &oneofField{
+			offset: field.offset,
+			typ:    runtime.RType2Type(field.typ),
+			name:   f.Name,
+			dec:    fieldDec,
+		}
+		dec.all = append(dec.all, dec.fields[i])
+	}
+	return dec, nil
+}
+
+func (d *oneofArrowDecoder) decode(arr arrow.Array, i int, p unsafe.Pointer) error {
+	for _, f := range d.all {
+		v := reflect.NewAt(f.typ, unsafe.Pointer(uintptr(p)+f.offset)).Elem()
+		v.Set(reflect.Zero(f.typ))
+	}
+	u := arr.(array.Union)
+	f := d.fields[u.ChildID(i)]
+	if f == nil {
+		return nil
+	}
+	child, j := unionChild(u, i)
+	if child.IsNull(j) {
+		return nil
+	}
+	if err := f.dec.decode(child, j, unsafe.Pointer(uintptr(p)+f.offset)); err != nil {
+		return annotateColumn(err, f.name)
+	}
+	return nil
+}
+
+func (d *oneofArrowDecoder) decodeRange(arr arrow.Array, from, to int, p unsafe.Pointer, stride uintptr) error {
+	return decodeRangeByValue(d, arr, from, to, p, stride)
+}
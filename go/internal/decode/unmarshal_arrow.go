@@ -0,0 +1,77 @@
+package decode
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+// ArrowUnmarshaler is implemented by types that decode themselves from the
+// i-th value of an arrow array, which is never null.
+type ArrowUnmarshaler interface {
+	UnmarshalArrow(arr arrow.Array, i int) error
+}
+
+// ArrowTyper is implemented by types that choose the arrow type of their
+// column. Columns of another type fail to decode into them.
+type ArrowTyper interface {
+	ArrowType() arrow.DataType
+}
+
+var (
+	unmarshalArrowType = reflect.TypeOf((*ArrowUnmarshaler)(nil)).Elem()
+	arrowTyperType     = reflect.TypeOf((*ArrowTyper)(nil)).Elem()
+)
+
+// unmarshalArrowDecoder decodes arrow values through ArrowUnmarshaler, and
+// JSON values through dec, the decoder the type would have without it.
+type unmarshalArrowDecoder struct {
+	typ        *runtime.Type
+	dec        Decoder
+	structName string
+	fieldName  string
+}
+
+func newUnmarshalArrowDecoder(typ *runtime.Type, dec Decoder, structName, fieldName string) *unmarshalArrowDecoder {
+	return &unmarshalArrowDecoder{
+		typ:        typ,
+		dec:        dec,
+		structName: structName,
+		fieldName:  fieldName,
+	}
+}
+
+func (d *unmarshalArrowDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	if arr.IsNull(i) {
+		return nil
+	}
+	v := *(*interface{})(unsafe.Pointer(&emptyInterface{
+		typ: d.typ,
+		ptr: p,
+	}))
+	return v.(ArrowUnmarshaler).UnmarshalArrow(arr, i)
+}
+
+func (d *unmarshalArrowDecoder) Decode(ctx *RuntimeContext, cursor, depth int64, p unsafe.Pointer) (int64, error) {
+	return d.dec.Decode(ctx, cursor, depth, p)
+}
+
+func (d *unmarshalArrowDecoder) DecodePath(ctx *RuntimeContext, cursor, depth int64) ([][]byte, int64, error) {
+	return d.dec.DecodePath(ctx, cursor, depth)
+}
+
+// arrowType returns the column type chosen by the ArrowTyper implementation
+// of typ, nil when it has none.
+func arrowType(typ *runtime.Type) arrow.DataType {
+	if !typ.Implements(arrowTyperType) {
+		return nil
+	}
+	v := *(*interface{})(unsafe.Pointer(&emptyInterface{
+		typ: typ,
+		ptr: unsafe_New(typ.Elem()),
+	}))
+	return v.(ArrowTyper).ArrowType()
+}
@@ -7,7 +7,6 @@ import (
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -38,6 +37,10 @@ func (d *unmarshalJSONDecoder) annotateError(cursor int64, err error) {
 }
 
 func (d *unmarshalJSONDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.decodeArrayIn(arr, i, p, ColumnTimeLocation)
+}
+
+func (d *unmarshalJSONDecoder) decodeArrayIn(arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
 	if arr.IsNull(i) {
 		return nil
 	}
@@ -46,21 +49,19 @@ func (d *unmarshalJSONDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Poin
 		typ: d.typ,
 		ptr: p,
 	}))
+	if ok, err := decodeDecimal(v, arr, i); ok {
+		return err
+	}
 	switch v := v.(type) {
 	case *time.Time:
-		if ts, ok := arr.(*array.Timestamp); ok {
-			t := ts.Value(i).ToTime(ts.DataType().(*arrow.TimestampType).Unit)
-			if l, err := time.LoadLocation(ts.DataType().(*arrow.TimestampType).TimeZone); err == nil {
-				*v = t.In(l)
-			} else {
-				*v = t
-			}
-		} else if td, ok := arr.(*array.Date32); ok {
-			*v = td.Value(i).ToTime()
+		if t, ok, err := timeValue(arr, i, loc); err != nil {
+			return err
+		} else if ok {
+			*v = t
 		}
 	case json.Unmarshaler:
-		if arr, ok := arr.(*array.String); ok {
-			if err := v.UnmarshalJSON([]byte(arr.Value(i))); err != nil {
+		if str, ok := stringValue(arr, i); ok {
+			if err := v.UnmarshalJSON([]byte(str)); err != nil {
 				return err
 			}
 		}
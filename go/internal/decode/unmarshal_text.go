@@ -11,7 +11,6 @@ import (
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
-	"github.com/apache/arrow/go/v10/arrow/array"
 
 	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
@@ -46,6 +45,10 @@ var (
 )
 
 func (d *unmarshalTextDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
+	return d.decodeArrayIn(arr, i, p, ColumnTimeLocation)
+}
+
+func (d *unmarshalTextDecoder) decodeArrayIn(arr arrow.Array, i int, p unsafe.Pointer, loc TimeLocation) error {
 	if arr.IsNull(i) {
 		*(*unsafe.Pointer)(p) = nil
 		return nil
@@ -60,21 +63,19 @@ func (d *unmarshalTextDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Poin
 		typ: d.typ,
 		ptr: p,
 	}))
+	if ok, err := decodeDecimal(v, arr, i); ok {
+		return err
+	}
 	switch v := v.(type) {
 	case *time.Time:
-		if ts, ok := arr.(*array.Timestamp); ok {
-			t := ts.Value(i).ToTime(arrow.Microsecond)
-			if l, err := time.LoadLocation(ts.DataType().(*arrow.TimestampType).TimeZone); err == nil {
-				*v = t.In(l)
-			} else {
-				*v = t
-			}
-		} else if td, ok := arr.(*array.Date64); ok {
-			*v = td.Value(i).ToTime()
+		if t, ok, err := timeValue(arr, i, loc); err != nil {
+			return err
+		} else if ok {
+			*v = t
 		}
 	case encoding.TextUnmarshaler:
-		if arr, ok := arr.(*array.String); ok {
-			if err := v.(encoding.TextUnmarshaler).UnmarshalText([]byte(arr.Value(i))); err != nil {
+		if str, ok := stringValue(arr, i); ok {
+			if err := v.UnmarshalText([]byte(str)); err != nil {
 				return err
 			}
 		}
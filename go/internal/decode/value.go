@@ -0,0 +1,130 @@
+package decode
+
+import (
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+)
+
+// ArrowValue returns the i-th value of arr as a generic Go value:
+// nil for null, bool, int64 for signed and uint64 for unsigned integers,
+// float64, string, []byte, time.Time for timestamps and dates, time.Duration
+// for durations and times of day, arrow.MonthDayNanoInterval, []interface{}
+// for lists and map[string]interface{} for structs and maps with string keys.
+// Extension arrays return the values of their storage, and unions the values
+// of their children. Timestamps of an invalid time zone fail.
+func ArrowValue(arr arrow.Array, i int) (interface{}, error) {
+	return arrowValue(arr, i, ColumnTimeLocation)
+}
+
+// arrowValue is ArrowValue decoding timestamps in the location selected by loc.
+func arrowValue(arr arrow.Array, i int, loc TimeLocation) (interface{}, error) {
+	if arr.IsNull(i) {
+		return nil, nil
+	}
+	switch a := arr.(type) {
+	case array.ExtensionArray:
+		return arrowValue(a.Storage(), i, loc)
+	case array.Union:
+		child, j := unionChild(a, i)
+		return arrowValue(child, j, loc)
+	case *array.Boolean:
+		return a.Value(i), nil
+	case *array.Uint8, *array.Uint16, *array.Uint32, *array.Uint64:
+		v, _ := uint64Value(arr, i)
+		return v, nil
+	case *array.Int8, *array.Int16, *array.Int32, *array.Int64:
+		v, _ := int64Value(arr, i)
+		return v, nil
+	case *array.Float32, *array.Float64:
+		v, _ := float64Value(arr, i)
+		return v, nil
+	case *array.String, *array.LargeString:
+		v, _ := stringValue(arr, i)
+		return cloneString(v), nil
+	case *array.Binary, *array.LargeBinary, *array.FixedSizeBinary:
+		src, _ := bytesValue(arr, i)
+		buf := make([]byte, len(src))
+		copy(buf, src)
+		return buf, nil
+	case *array.Timestamp, *array.Date32, *array.Date64:
+		v, _, err := timeValue(arr, i, loc)
+		return v, err
+	case *array.Duration, *array.Time32, *array.Time64:
+		v, _ := durationValue(arr, i)
+		return v, nil
+	case *array.MonthDayNanoInterval:
+		return a.Value(i), nil
+	case *array.Struct:
+		st := a.DataType().(*arrow.StructType)
+		m := make(map[string]interface{}, len(st.Fields()))
+		for idx, f := range st.Fields() {
+			v, err := arrowValue(a.Field(idx), i, loc)
+			if err != nil {
+				return nil, annotateColumn(err, f.Name)
+			}
+			m[f.Name] = v
+		}
+		return m, nil
+	case *array.Map:
+		start, end := a.ValueOffsets(i)
+		keys, items := a.Keys(), a.Items()
+		m := make(map[string]interface{}, end-start)
+		for idx := int(start); idx < int(end); idx++ {
+			key, ok := stringValue(keys, idx)
+			if !ok {
+				return nil, errArrowType("", arr.DataType(), emptyInterfaceType)
+			}
+			v, err := arrowValue(items, idx, loc)
+			if err != nil {
+				return nil, annotateElement(err)
+			}
+			m[cloneString(key)] = v
+		}
+		return m, nil
+	}
+	values, start, end, ok := listValueRange(arr, i)
+	if !ok {
+		return nil, errArrowType("", arr.DataType(), emptyInterfaceType)
+	}
+	list := make([]interface{}, end-start)
+	for idx := range list {
+		v, err := arrowValue(values, start+idx, loc)
+		if err != nil {
+			return nil, annotateElement(err)
+		}
+		list[idx] = v
+	}
+	return list, nil
+}
+
+// isArrowValueType reports whether ArrowValue supports every value of dt.
+func isArrowValueType(dt arrow.DataType) bool {
+	if _, _, ok := integerWidth(dt); ok || isStringType(dt) {
+		return true
+	}
+	switch t := dt.(type) {
+	case arrow.ExtensionType:
+		return isArrowValueType(t.StorageType())
+	case *arrow.BooleanType, *arrow.Float32Type, *arrow.Float64Type,
+		*arrow.TimestampType, *arrow.Date32Type, *arrow.Date64Type,
+		*arrow.DurationType, *arrow.Time32Type, *arrow.Time64Type, *arrow.MonthDayNanoIntervalType:
+		return true
+	case *arrow.StructType, arrow.UnionType:
+		for _, f := range t.(arrow.NestedType).Fields() {
+			if !isArrowValueType(f.Type) {
+				return false
+			}
+		}
+		return true
+	case *arrow.MapType:
+		switch t.KeyType().ID() {
+		case arrow.STRING, arrow.LARGE_STRING:
+			return isArrowValueType(t.ItemType())
+		}
+		return false
+	}
+	if elem, ok := listElemType(dt); ok {
+		return isArrowValueType(elem)
+	}
+	return false
+}
@@ -3,10 +3,12 @@ package decode
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
 
+	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/client/go/internal/runtime"
 )
 
@@ -30,12 +32,34 @@ func newWrappedStringDecoder(typ *runtime.Type, dec Decoder, structName, fieldNa
 	}
 }
 
+// DecodeArray decodes string columns holding the JSON text of the value, as
+// encoding/json quotes it, and other columns as the wrapped decoder does.
 func (d *wrappedStringDecoder) DecodeArray(arr arrow.Array, i int, p unsafe.Pointer) error {
 	if arr.IsNull(i) {
-		*(*unsafe.Pointer)(p) = nil
+		if d.isPtrType {
+			*(*unsafe.Pointer)(p) = nil
+		}
 		return nil
 	}
-	return d.dec.DecodeArray(arr, i, p)
+	s, ok := stringValue(arr, i)
+	if !ok {
+		return d.dec.DecodeArray(arr, i, p)
+	}
+	// decoded strings share the buffer, which must outlive the context
+	buf := make([]byte, len(s)+1)
+	copy(buf, s)
+	ctx := TakeRuntimeContext()
+	oldBuf := ctx.Buf
+	ctx.Buf = buf
+	_, err := d.dec.Decode(ctx, 0, 0, p)
+	ctx.Buf = oldBuf
+	ReleaseRuntimeContext(ctx)
+	if err != nil {
+		e := errors.ErrArrowType("", arr.DataType(), runtime.RType2Type(d.typ), -1)
+		e.Value = strconv.Quote(s)
+		return e
+	}
+	return nil
 }
 
 func (d *wrappedStringDecoder) Decode(ctx *RuntimeContext, cursor, depth int64, p unsafe.Pointer) (int64, error) {
@@ -0,0 +1,364 @@
+package encode
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/bitutil"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+)
+
+// newBuilder returns a builder of dt as array.NewBuilder does, except for
+// unions and the list, struct and map types holding them. The union builders
+// of arrow v10 retain and release their children on construction, which
+// releases the field builders of struct children for good, and the builders
+// of nested types construct their children themselves. Those types are built
+// with the builders below, whose children are built by newBuilder in turn.
+func newBuilder(mem memory.Allocator, dt arrow.DataType) array.Builder {
+	if !hasUnion(dt) {
+		return array.NewBuilder(mem, dt)
+	}
+	switch t := dt.(type) {
+	case arrow.UnionType:
+		return newUnionBuilder(mem, t)
+	case *arrow.StructType:
+		b := &structBuilder{nestedBuilder: newNestedBuilder(mem, dt)}
+		for _, f := range t.Fields() {
+			b.fields = append(b.fields, newBuilder(mem, f.Type))
+		}
+		return b
+	case *arrow.MapType:
+		return &mapBuilder{
+			nestedBuilder: newNestedBuilder(mem, dt),
+			keys:          newBuilder(mem, t.KeyType()),
+			items:         newBuilder(mem, t.ItemType()),
+		}
+	}
+	elem, _ := listElemField(dt)
+	return &listBuilder{nestedBuilder: newNestedBuilder(mem, dt), values: newBuilder(mem, elem.Type)}
+}
+
+// hasUnion reports whether dt is a union or a list, struct or map type
+// holding one.
+func hasUnion(dt arrow.DataType) bool {
+	switch t := dt.(type) {
+	case arrow.UnionType:
+		return true
+	case *arrow.StructType:
+		for _, f := range t.Fields() {
+			if hasUnion(f.Type) {
+				return true
+			}
+		}
+		return false
+	case *arrow.MapType:
+		return hasUnion(t.KeyType()) || hasUnion(t.ItemType())
+	}
+	if elem, ok := listElemField(dt); ok {
+		return hasUnion(elem.Type)
+	}
+	return false
+}
+
+// nestedBuilder holds the type, reference count and validity of the values
+// of the builders below. They implement every exported method of
+// array.Builder; the embedded array.Builder is nil, arrow calling its
+// unexported methods on builders it constructed only.
+type nestedBuilder struct {
+	array.Builder
+	mem   memory.Allocator
+	dt    arrow.DataType
+	refs  int64
+	valid []bool
+	nulls int
+}
+
+func newNestedBuilder(mem memory.Allocator, dt arrow.DataType) nestedBuilder {
+	return nestedBuilder{mem: mem, dt: dt, refs: 1}
+}
+
+func (b *nestedBuilder) Type() arrow.DataType { return b.dt }
+func (b *nestedBuilder) Retain()              { atomic.AddInt64(&b.refs, 1) }
+func (b *nestedBuilder) Len() int             { return len(b.valid) }
+func (b *nestedBuilder) Cap() int             { return cap(b.valid) }
+func (b *nestedBuilder) NullN() int           { return b.nulls }
+func (b *nestedBuilder) Reserve(n int)        {}
+func (b *nestedBuilder) Resize(n int)         {}
+
+func (b *nestedBuilder) UnmarshalJSON([]byte) error {
+	return fmt.Errorf("arrow/encode: cannot unmarshal JSON into a builder of %s", b.dt)
+}
+
+// release decreases the reference count, releasing children with the last
+// reference.
+func (b *nestedBuilder) release(children ...array.Builder) {
+	if atomic.AddInt64(&b.refs, -1) == 0 {
+		for _, c := range children {
+			c.Release()
+		}
+	}
+}
+
+func (b *nestedBuilder) appendValid(valid bool) {
+	b.valid = append(b.valid, valid)
+	if !valid {
+		b.nulls++
+	}
+}
+
+// finish returns the length, validity bitmap and null count of the values
+// appended since the last call, the bitmap being nil without nulls, and
+// resets them.
+func (b *nestedBuilder) finish() (int, *memory.Buffer, int) {
+	n, nulls := len(b.valid), b.nulls
+	var bitmap *memory.Buffer
+	if nulls > 0 {
+		bitmap = memory.NewResizableBuffer(b.mem)
+		bitmap.Resize(int(bitutil.BytesForBits(int64(n))))
+		bits := bitmap.Bytes()
+		for i := range bits {
+			bits[i] = 0
+		}
+		for i, valid := range b.valid {
+			if valid {
+				bitutil.SetBit(bits, i)
+			}
+		}
+	}
+	b.valid, b.nulls = b.valid[:0], 0
+	return n, bitmap, nulls
+}
+
+// newBuffer returns a buffer allocated from mem holding a copy of src.
+func newBuffer(mem memory.Allocator, src []byte) *memory.Buffer {
+	buf := memory.NewResizableBuffer(mem)
+	buf.Resize(len(src))
+	copy(buf.Bytes(), src)
+	return buf
+}
+
+// newArray returns the array of data, releasing data and the buffers and
+// children it holds.
+func newArray(data *array.Data, buffers []*memory.Buffer, children []arrow.Array) arrow.Array {
+	defer data.Release()
+	for _, buf := range buffers {
+		if buf != nil {
+			buf.Release()
+		}
+	}
+	for _, c := range children {
+		c.Release()
+	}
+	return array.MakeFromData(data)
+}
+
+// childData returns the data of the arrays children.
+func childData(children []arrow.Array) []arrow.ArrayData {
+	data := make([]arrow.ArrayData, len(children))
+	for i, c := range children {
+		data[i] = c.Data()
+	}
+	return data
+}
+
+// structBuilder builds struct columns holding unions.
+type structBuilder struct {
+	nestedBuilder
+	fields []array.Builder
+}
+
+func (b *structBuilder) Release() { b.release(b.fields...) }
+
+// Append appends a struct, nulls to the fields when it is null. The fields
+// of valid structs are appended to their builders.
+func (b *structBuilder) Append(valid bool) {
+	b.appendValid(valid)
+	if !valid {
+		for _, f := range b.fields {
+			f.AppendNull()
+		}
+	}
+}
+
+func (b *structBuilder) AppendNull() { b.Append(false) }
+
+func (b *structBuilder) AppendEmptyValue() {
+	b.Append(true)
+	for _, f := range b.fields {
+		f.AppendEmptyValue()
+	}
+}
+
+func (b *structBuilder) FieldBuilder(i int) array.Builder { return b.fields[i] }
+
+func (b *structBuilder) NewArray() arrow.Array {
+	n, bitmap, nulls := b.finish()
+	children := make([]arrow.Array, len(b.fields))
+	for i, f := range b.fields {
+		children[i] = f.NewArray()
+	}
+	buffers := []*memory.Buffer{bitmap}
+	return newArray(array.NewData(b.dt, n, buffers, childData(children), nulls, 0), buffers, children)
+}
+
+// listBuilder builds list, large list and fixed size list columns holding
+// unions.
+type listBuilder struct {
+	nestedBuilder
+	values  array.Builder
+	offsets []int64
+}
+
+func (b *listBuilder) Release() { b.release(b.values) }
+
+// Append appends a list, whose values are then appended to ValueBuilder.
+// Fixed size lists hold as many values when they are null.
+func (b *listBuilder) Append(valid bool) {
+	b.appendValid(valid)
+	b.offsets = append(b.offsets, int64(b.values.Len()))
+	if t, ok := b.dt.(*arrow.FixedSizeListType); ok && !valid {
+		for i := int32(0); i < t.Len(); i++ {
+			b.values.AppendNull()
+		}
+	}
+}
+
+func (b *listBuilder) AppendNull() { b.Append(false) }
+
+func (b *listBuilder) AppendEmptyValue() {
+	b.Append(true)
+	if t, ok := b.dt.(*arrow.FixedSizeListType); ok {
+		for i := int32(0); i < t.Len(); i++ {
+			b.values.AppendEmptyValue()
+		}
+	}
+}
+
+func (b *listBuilder) ValueBuilder() array.Builder { return b.values }
+
+func (b *listBuilder) NewArray() arrow.Array {
+	n, bitmap, nulls := b.finish()
+	buffers := []*memory.Buffer{bitmap}
+	switch b.dt.ID() {
+	case arrow.LIST:
+		offsets := make([]int32, 0, n+1)
+		for _, o := range b.offsets {
+			offsets = append(offsets, int32(o))
+		}
+		buffers = append(buffers, newBuffer(b.mem, arrow.Int32Traits.CastToBytes(append(offsets, int32(b.values.Len())))))
+	case arrow.LARGE_LIST:
+		buffers = append(buffers, newBuffer(b.mem, arrow.Int64Traits.CastToBytes(append(b.offsets, int64(b.values.Len())))))
+	}
+	b.offsets = b.offsets[:0]
+	children := []arrow.Array{b.values.NewArray()}
+	return newArray(array.NewData(b.dt, n, buffers, childData(children), nulls, 0), buffers, children)
+}
+
+// mapBuilder builds map columns holding unions.
+type mapBuilder struct {
+	nestedBuilder
+	keys, items array.Builder
+	offsets     []int32
+}
+
+func (b *mapBuilder) Release() { b.release(b.keys, b.items) }
+
+// Append appends a map, whose entries are then appended to KeyBuilder and
+// ItemBuilder.
+func (b *mapBuilder) Append(valid bool) {
+	b.appendValid(valid)
+	b.offsets = append(b.offsets, int32(b.keys.Len()))
+}
+
+func (b *mapBuilder) AppendNull()       { b.Append(false) }
+func (b *mapBuilder) AppendEmptyValue() { b.Append(true) }
+
+func (b *mapBuilder) KeyBuilder() array.Builder  { return b.keys }
+func (b *mapBuilder) ItemBuilder() array.Builder { return b.items }
+
+func (b *mapBuilder) NewArray() arrow.Array {
+	n, bitmap, nulls := b.finish()
+	entries := b.keys.Len()
+	buffers := []*memory.Buffer{bitmap, newBuffer(b.mem, arrow.Int32Traits.CastToBytes(append(b.offsets, int32(entries))))}
+	b.offsets = b.offsets[:0]
+	children := []arrow.Array{b.keys.NewArray(), b.items.NewArray()}
+	entryData := array.NewData(b.dt.(*arrow.MapType).ValueType(), entries, []*memory.Buffer{nil}, childData(children), 0, 0)
+	defer entryData.Release()
+	return newArray(array.NewData(b.dt, n, buffers, []arrow.ArrayData{entryData}, nulls, 0), buffers, children)
+}
+
+// unionBuilder builds union columns. Unions have no validity of their own:
+// a null is a null of their first child.
+type unionBuilder struct {
+	nestedBuilder
+	ut       arrow.UnionType
+	children []array.Builder
+	childIDs []int // by type code
+	codes    []arrow.UnionTypeCode
+	offsets  []int32 // of dense unions
+}
+
+func newUnionBuilder(mem memory.Allocator, ut arrow.UnionType) *unionBuilder {
+	b := &unionBuilder{
+		nestedBuilder: newNestedBuilder(mem, ut),
+		ut:            ut,
+		childIDs:      ut.ChildIDs(),
+	}
+	for _, f := range ut.Fields() {
+		b.children = append(b.children, newBuilder(mem, f.Type))
+	}
+	return b
+}
+
+func (b *unionBuilder) Release()              { b.release(b.children...) }
+func (b *unionBuilder) Len() int              { return len(b.codes) }
+func (b *unionBuilder) Cap() int              { return cap(b.codes) }
+func (b *unionBuilder) NullN() int            { return 0 }
+func (b *unionBuilder) Mode() arrow.UnionMode { return b.ut.Mode() }
+
+// Child returns the builder of the i-th child.
+func (b *unionBuilder) Child(i int) array.Builder { return b.children[i] }
+
+// Append appends a value of the child of code, the value then being appended
+// to the builder of the child. The other children of sparse unions are left
+// for the caller to append to.
+func (b *unionBuilder) Append(code arrow.UnionTypeCode) {
+	b.codes = append(b.codes, code)
+	if b.ut.Mode() == arrow.DenseMode {
+		b.offsets = append(b.offsets, int32(b.children[b.childIDs[code]].Len()))
+	}
+}
+
+func (b *unionBuilder) AppendNull() {
+	b.Append(b.ut.TypeCodes()[0])
+	for i, c := range b.children {
+		if i == 0 || b.ut.Mode() == arrow.SparseMode {
+			c.AppendNull()
+		}
+	}
+}
+
+func (b *unionBuilder) AppendEmptyValue() {
+	b.Append(b.ut.TypeCodes()[0])
+	for i, c := range b.children {
+		if i == 0 || b.ut.Mode() == arrow.SparseMode {
+			c.AppendEmptyValue()
+		}
+	}
+}
+
+func (b *unionBuilder) NewArray() arrow.Array {
+	n := len(b.codes)
+	buffers := []*memory.Buffer{nil, newBuffer(b.mem, arrow.Int8Traits.CastToBytes(b.codes))}
+	if b.ut.Mode() == arrow.DenseMode {
+		buffers = append(buffers, newBuffer(b.mem, arrow.Int32Traits.CastToBytes(b.offsets)))
+	}
+	b.codes, b.offsets = b.codes[:0], b.offsets[:0]
+	children := make([]arrow.Array, len(b.children))
+	for i, c := range b.children {
+		children[i] = c.NewArray()
+	}
+	return newArray(array.NewData(b.dt, n, buffers, childData(children), 0, 0), buffers, children)
+}
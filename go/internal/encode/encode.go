@@ -0,0 +1,980 @@
+package encode
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+
+	"github.com/chronowave/client/go/internal/decode"
+	"github.com/chronowave/client/go/internal/errors"
+)
+
+// DecimalMarshaler is implemented by types that encode into an arrow decimal
+// column losslessly, the encoded number being unscaled * 10^-scale.
+type DecimalMarshaler interface {
+	MarshalDecimal() (unscaled *big.Int, scale int32, err error)
+}
+
+// ArrowMarshaler is implemented by types that encode themselves by appending
+// one value to the builder of their column.
+type ArrowMarshaler interface {
+	MarshalArrow(b array.Builder) error
+}
+
+var (
+	marshalArrowType     = reflect.TypeOf((*ArrowMarshaler)(nil)).Elem()
+	arrowTyperType       = reflect.TypeOf((*decode.ArrowTyper)(nil)).Elem()
+	timeType             = reflect.TypeOf(time.Time{})
+	durationType         = reflect.TypeOf(time.Duration(0))
+	marshalJSONType      = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	marshalTextType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	marshalDecimalType   = reflect.TypeOf((*DecimalMarshaler)(nil)).Elem()
+	bigIntType           = reflect.TypeOf(big.Int{})
+	bigRatType           = reflect.TypeOf(big.Rat{})
+	bigFloatType         = reflect.TypeOf(big.Float{})
+	stringInterfaceTypes = map[reflect.Type]bool{marshalJSONType: true, marshalTextType: true}
+)
+
+// Marshal encodes v, a slice or array of structs or of maps with string keys,
+// or a pointer to one, into a record of schema allocated from mem. Each
+// element becomes a row, its fields or entries being matched with the
+// columns by name as the decoder does. Columns without a matching value are
+// null. The caller releases the record.
+func Marshal(mem memory.Allocator, schema *arrow.Schema, v interface{}) (arrow.Record, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, errors.ErrInvalidRecordMarshal(reflect.TypeOf(v))
+	}
+
+	fields, err := compileFields(rv.Type().Elem(), schema.Fields())
+	if err != nil {
+		return nil, err
+	}
+
+	builders := make([]array.Builder, len(schema.Fields()))
+	for i, f := range schema.Fields() {
+		builders[i] = newBuilder(mem, f.Type)
+		defer builders[i].Release()
+		builders[i].Reserve(rv.Len())
+	}
+	field := func(i int) array.Builder { return builders[i] }
+	for i := 0; i < rv.Len(); i++ {
+		if err := fields.encodeFields(field, rv.Index(i)); err != nil {
+			return nil, annotateRow(err, i)
+		}
+	}
+	columns := make([]arrow.Array, len(builders))
+	for i, b := range builders {
+		columns[i] = b.NewArray()
+		defer columns[i].Release()
+	}
+	return array.NewRecord(schema, columns, int64(rv.Len())), nil
+}
+
+// arrowEncoder appends Go values to the builder of one arrow column, the
+// column type having been matched with the Go type when it was compiled.
+type arrowEncoder interface {
+	encode(b array.Builder, v reflect.Value) error
+}
+
+// fieldsEncoder appends a Go value to the builders of several columns, such
+// as the fields of a struct column or the columns of a record.
+type fieldsEncoder interface {
+	encodeFields(field func(int) array.Builder, v reflect.Value) error
+}
+
+// compile returns the encoder of values of t into columns of type dt.
+// layout is the LAYOUT metadata of the column field, used to format times
+// into string and integer columns.
+func compile(t reflect.Type, dt arrow.DataType, layout string) (arrowEncoder, error) {
+	if ext := decode.LookupExtension(t); ext != nil {
+		if !ext.Accepts(dt) {
+			return nil, errors.ErrArrowMarshalType("", dt, t, -1)
+		}
+		var enc arrowEncoder = &registeredEncoder{ext: ext}
+		if dt.ID() == arrow.EXTENSION {
+			enc = &extensionEncoder{elem: enc}
+		}
+		return enc, nil
+	}
+	if enc, ok := compileBinary(t, dt); ok {
+		return enc, nil
+	}
+	switch {
+	case t.Kind() == reflect.Ptr:
+		elem, err := compile(t.Elem(), dt, layout)
+		if err != nil {
+			return nil, err
+		}
+		return &ptrEncoder{elem: elem}, nil
+	case t.Kind() != reflect.Interface && implements(t, marshalArrowType):
+		if want := arrowType(t); want != nil && !arrow.TypeEqual(dt, want) {
+			return nil, errors.ErrArrowMarshalType("", dt, t, -1)
+		}
+		return &arrowMarshalerEncoder{}, nil
+	case dt.ID() == arrow.EXTENSION:
+		elem, err := compile(t, dt.(arrow.ExtensionType).StorageType(), layout)
+		if err != nil {
+			return nil, err
+		}
+		return &extensionEncoder{elem: elem}, nil
+	case layout == decode.JSONLayout && isStringType(dt) && isJSONTextKind(t.Kind()):
+		return &jsonTextEncoder{}, nil
+	case t.Kind() == reflect.Interface && isUnionType(dt) && decode.LookupUnion(t) != nil:
+		return compileUnionInterface(t, dt.(arrow.UnionType), decode.LookupUnion(t))
+	case t.Kind() == reflect.Interface && !stringInterfaceTypes[t]:
+		return &interfaceEncoder{dt: dt, layout: layout, encoders: map[reflect.Type]arrowEncoder{}}, nil
+	case isDecimalType(dt):
+		if isDecimalGoType(t) {
+			return &decimalEncoder{}, nil
+		}
+	case t == timeType:
+		if isTimeType(dt) {
+			return &timeEncoder{layout: decode.NewTimeLayout(layout)}, nil
+		}
+	case t == durationType && isDurationType(dt):
+		return &durationEncoder{}, nil
+	case t == intervalType:
+		if dt.ID() == arrow.INTERVAL_MONTH_DAY_NANO {
+			return &intervalEncoder{}, nil
+		}
+	case implements(t, marshalJSONType) || implements(t, marshalTextType):
+		if isStringType(dt) {
+			return &marshalerEncoder{json: implements(t, marshalJSONType)}, nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if dt.ID() == arrow.BOOL {
+			return &boolEncoder{}, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if isIntegerType(dt) || isFloatType(dt) {
+			return &numberEncoder{}, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if isFloatType(dt) {
+			return &numberEncoder{}, nil
+		}
+	case reflect.String:
+		if isStringType(dt) {
+			return &stringEncoder{}, nil
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && isStringType(dt) {
+			return &bytesEncoder{}, nil
+		}
+		if elemField, ok := listElemField(dt); ok {
+			elem, err := compile(t.Elem(), elemField.Type, decode.FieldLayout(elemField, layout))
+			if err != nil {
+				return nil, annotateElement(err)
+			}
+			return &listEncoder{elem: elem}, nil
+		}
+	case reflect.Map:
+		if mt, ok := dt.(*arrow.MapType); ok {
+			key, err := compile(t.Key(), mt.KeyType(), "")
+			if err != nil {
+				return nil, annotateElement(err)
+			}
+			item, err := compile(t.Elem(), mt.ItemType(), decode.FieldLayout(mt.ItemField(), ""))
+			if err != nil {
+				return nil, annotateElement(err)
+			}
+			return &mapEncoder{key: key, item: item}, nil
+		}
+		if st, ok := dt.(*arrow.StructType); ok && t.Key().Kind() == reflect.String {
+			fields, err := compileFields(t, st.Fields())
+			if err != nil {
+				return nil, err
+			}
+			return &structEncoder{fields: fields}, nil
+		}
+	case reflect.Struct:
+		if ut, ok := dt.(arrow.UnionType); ok {
+			return compileOneof(t, ut)
+		}
+		if st, ok := dt.(*arrow.StructType); ok {
+			fields, err := compileFields(t, st.Fields())
+			if err != nil {
+				return nil, err
+			}
+			return &structEncoder{fields: fields}, nil
+		}
+	}
+	return nil, errors.ErrArrowMarshalType("", dt, t, -1)
+}
+
+// compileFields returns the encoder of values of t into the columns fields,
+// t being a struct, a map with string keys, or a pointer or interface
+// holding one.
+func compileFields(t reflect.Type, fields []arrow.Field) (fieldsEncoder, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := compileFields(t.Elem(), fields)
+		if err != nil {
+			return nil, err
+		}
+		return &ptrFieldsEncoder{elem: elem, n: len(fields)}, nil
+	case reflect.Interface:
+		return &interfaceFieldsEncoder{fields: fields, encoders: map[reflect.Type]fieldsEncoder{}}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		enc := &mapFieldsEncoder{fields: make([]mapField, len(fields))}
+		for i, f := range fields {
+			elem, err := compile(t.Elem(), f.Type, decode.FieldLayout(f, ""))
+			if err != nil {
+				return nil, annotateColumn(err, f.Name)
+			}
+			enc.fields[i] = mapField{key: reflect.ValueOf(f.Name).Convert(t.Key()), enc: elem}
+		}
+		return enc, nil
+	case reflect.Struct:
+		goFields := TypeFields(t)
+		enc := &structFieldsEncoder{fields: make([]structField, len(fields))}
+		for i, f := range fields {
+			goField, ok := lookupField(goFields, f.Name)
+			if !ok {
+				continue
+			}
+			elem, err := compileField(goField, f)
+			if err != nil {
+				return nil, annotateColumn(err, f.Name)
+			}
+			enc.fields[i] = structField{name: f.Name, index: goField.Index, enc: elem}
+		}
+		return enc, nil
+	}
+	return nil, errors.ErrArrowMarshalType("", arrow.StructOf(fields...), t, -1)
+}
+
+// compileField returns the encoder of the struct field f into column c,
+// honouring the string and omitempty options of its json tag.
+func compileField(f Field, c arrow.Field) (arrowEncoder, error) {
+	var (
+		enc arrowEncoder
+		err error
+	)
+	if f.Quoted && isStringType(c.Type) {
+		enc = &quotedEncoder{}
+	} else if enc, err = compile(f.Field.Type, c.Type, decode.FieldLayout(c, "")); err != nil {
+		return nil, err
+	}
+	if f.OmitEmpty {
+		enc = &omitEmptyEncoder{elem: enc}
+	}
+	return enc, nil
+}
+
+// lookupField returns the field matched with the column name as the decoder
+// does: by name, else by alias, else case-insensitively.
+func lookupField(fields []Field, name string) (Field, bool) {
+	for _, match := range []func(f Field) bool{
+		func(f Field) bool { return f.Name == name },
+		func(f Field) bool { return containsName(f.Aliases, name, false) },
+		func(f Field) bool { return strings.EqualFold(f.Name, name) },
+		func(f Field) bool { return containsName(f.Aliases, name, true) },
+	} {
+		for _, f := range fields {
+			if match(f) {
+				return f, true
+			}
+		}
+	}
+	return Field{}, false
+}
+
+func containsName(names []string, name string, fold bool) bool {
+	for _, n := range names {
+		if n == name || fold && strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+type ptrEncoder struct {
+	elem arrowEncoder
+}
+
+func (e *ptrEncoder) encode(b array.Builder, v reflect.Value) error {
+	if v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	return e.elem.encode(b, v.Elem())
+}
+
+// interfaceEncoder encodes the dynamic value of an interface, compiling an
+// encoder for each dynamic type met.
+type interfaceEncoder struct {
+	dt       arrow.DataType
+	layout   string
+	encoders map[reflect.Type]arrowEncoder
+}
+
+func (e *interfaceEncoder) encode(b array.Builder, v reflect.Value) error {
+	if v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	v = v.Elem()
+	enc, exists := e.encoders[v.Type()]
+	if !exists {
+		var err error
+		if enc, err = compile(v.Type(), e.dt, e.layout); err != nil {
+			return err
+		}
+		e.encoders[v.Type()] = enc
+	}
+	return enc.encode(b, v)
+}
+
+// structLikeBuilder is implemented by the builders of struct columns:
+// *array.StructBuilder, and the one of struct columns holding unions.
+type structLikeBuilder interface {
+	array.Builder
+	Append(bool)
+	FieldBuilder(int) array.Builder
+}
+
+type structEncoder struct {
+	fields fieldsEncoder
+}
+
+func (e *structEncoder) encode(b array.Builder, v reflect.Value) error {
+	sb := b.(structLikeBuilder)
+	sb.Append(true)
+	return e.fields.encodeFields(sb.FieldBuilder, v)
+}
+
+type structField struct {
+	name  string
+	index []int
+	enc   arrowEncoder // nil for columns without a matching field
+}
+
+type structFieldsEncoder struct {
+	fields []structField
+}
+
+func (e *structFieldsEncoder) encodeFields(field func(int) array.Builder, v reflect.Value) error {
+	for i, f := range e.fields {
+		b := field(i)
+		fv, ok := fieldByIndex(v, f.index)
+		if f.enc == nil || !ok {
+			b.AppendNull()
+			continue
+		}
+		if err := f.enc.encode(b, fv); err != nil {
+			return annotateColumn(err, f.name)
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex reporting false when the field
+// is reached through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+type mapField struct {
+	key reflect.Value
+	enc arrowEncoder
+}
+
+// mapFieldsEncoder encodes the entries of a map into the columns named by their keys.
+type mapFieldsEncoder struct {
+	fields []mapField
+}
+
+func (e *mapFieldsEncoder) encodeFields(field func(int) array.Builder, v reflect.Value) error {
+	for i, f := range e.fields {
+		b := field(i)
+		if v.IsNil() {
+			b.AppendNull()
+			continue
+		}
+		fv := v.MapIndex(f.key)
+		if !fv.IsValid() {
+			b.AppendNull()
+			continue
+		}
+		if err := f.enc.encode(b, fv); err != nil {
+			return annotateColumn(err, f.key.String())
+		}
+	}
+	return nil
+}
+
+// ptrFieldsEncoder appends nulls to the n columns for nil pointers.
+type ptrFieldsEncoder struct {
+	elem fieldsEncoder
+	n    int
+}
+
+func (e *ptrFieldsEncoder) encodeFields(field func(int) array.Builder, v reflect.Value) error {
+	if v.IsNil() {
+		for i := 0; i < e.n; i++ {
+			field(i).AppendNull()
+		}
+		return nil
+	}
+	return e.elem.encodeFields(field, v.Elem())
+}
+
+type interfaceFieldsEncoder struct {
+	fields   []arrow.Field
+	encoders map[reflect.Type]fieldsEncoder
+}
+
+func (e *interfaceFieldsEncoder) encodeFields(field func(int) array.Builder, v reflect.Value) error {
+	if v.IsNil() {
+		for i := range e.fields {
+			field(i).AppendNull()
+		}
+		return nil
+	}
+	v = v.Elem()
+	enc, exists := e.encoders[v.Type()]
+	if !exists {
+		var err error
+		if enc, err = compileFields(v.Type(), e.fields); err != nil {
+			return err
+		}
+		e.encoders[v.Type()] = enc
+	}
+	return enc.encodeFields(field, v)
+}
+
+// listEncoder encodes slices and arrays into list columns, nil slices as nulls.
+type listEncoder struct {
+	elem arrowEncoder
+}
+
+func (e *listEncoder) encode(b array.Builder, v reflect.Value) error {
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	lb := b.(array.ListLikeBuilder)
+	if ft, ok := b.Type().(*arrow.FixedSizeListType); ok {
+		if n := ft.Len(); int(n) != v.Len() {
+			return valueError(b, v, "length "+strconv.Itoa(v.Len()))
+		}
+	}
+	lb.Append(true)
+	values := lb.ValueBuilder()
+	for i := 0; i < v.Len(); i++ {
+		if err := e.elem.encode(values, v.Index(i)); err != nil {
+			return annotateElement(err)
+		}
+	}
+	return nil
+}
+
+// mapLikeBuilder is implemented by the builders of map columns:
+// *array.MapBuilder, and the one of map columns holding unions.
+type mapLikeBuilder interface {
+	array.Builder
+	Append(bool)
+	KeyBuilder() array.Builder
+	ItemBuilder() array.Builder
+}
+
+// mapEncoder encodes Go maps into map columns, nil maps as nulls.
+type mapEncoder struct {
+	key, item arrowEncoder
+}
+
+func (e *mapEncoder) encode(b array.Builder, v reflect.Value) error {
+	if v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	mb := b.(mapLikeBuilder)
+	mb.Append(true)
+	iter := v.MapRange()
+	for iter.Next() {
+		if err := e.key.encode(mb.KeyBuilder(), iter.Key()); err != nil {
+			return annotateElement(err)
+		}
+		if err := e.item.encode(mb.ItemBuilder(), iter.Value()); err != nil {
+			return annotateElement(err)
+		}
+	}
+	return nil
+}
+
+// omitEmptyEncoder encodes the empty values of fields tagged omitempty, which
+// encoding/json leaves out, as nulls.
+type omitEmptyEncoder struct {
+	elem arrowEncoder
+}
+
+func (e *omitEmptyEncoder) encode(b array.Builder, v reflect.Value) error {
+	if isEmptyValue(v) {
+		b.AppendNull()
+		return nil
+	}
+	return e.elem.encode(b, v)
+}
+
+// isEmptyValue reports whether encoding/json omits v from fields tagged omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// quotedEncoder encodes fields tagged with the json string option into string
+// columns as the JSON text of their value, which the decoder reads back.
+type quotedEncoder struct{}
+
+func (e *quotedEncoder) encode(b array.Builder, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			b.AppendNull()
+			return nil
+		}
+		v = v.Elem()
+	}
+	buf, err := json.Marshal(v.Interface())
+	if err != nil {
+		return valueError(b, v, err.Error())
+	}
+	if !appendBytes(b, buf) {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+// jsonTextEncoder encodes values into string columns with decode.JSONLayout
+// as their JSON text, nil slices and maps as nulls.
+type jsonTextEncoder struct{}
+
+func (e *jsonTextEncoder) encode(b array.Builder, v reflect.Value) error {
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	buf, err := json.Marshal(v.Interface())
+	if err != nil {
+		return valueError(b, v, err.Error())
+	}
+	if !appendBytes(b, buf) {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+func isJSONTextKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+type boolEncoder struct{}
+
+func (e *boolEncoder) encode(b array.Builder, v reflect.Value) error {
+	b.(*array.BooleanBuilder).Append(v.Bool())
+	return nil
+}
+
+// numberEncoder encodes Go numbers into integer and float columns, failing
+// on values out of the column range.
+type numberEncoder struct{}
+
+func (e *numberEncoder) encode(b array.Builder, v reflect.Value) error {
+	var ok bool
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ok = appendInt(b, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		ok = appendUint(b, v.Uint(), v.Type().Bits())
+	default:
+		ok = appendFloat(b, v.Float())
+	}
+	if !ok {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+type stringEncoder struct{}
+
+func (e *stringEncoder) encode(b array.Builder, v reflect.Value) error {
+	if !appendString(b, v.String()) {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+// bytesEncoder encodes byte slices and arrays into binary and string columns,
+// nil slices as nulls.
+type bytesEncoder struct{}
+
+func (e *bytesEncoder) encode(b array.Builder, v reflect.Value) error {
+	var buf []byte
+	if v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			b.AppendNull()
+			return nil
+		}
+		buf = v.Bytes()
+	} else {
+		buf = make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(buf), v)
+	}
+	if !appendBytes(b, buf) {
+		return valueError(b, v, "length "+strconv.Itoa(len(buf)))
+	}
+	return nil
+}
+
+// timeEncoder encodes time.Time into timestamp, date and time of day columns,
+// and into string and integer columns formatted with the field layout.
+type timeEncoder struct {
+	layout decode.TimeLayout
+}
+
+func (e *timeEncoder) encode(b array.Builder, v reflect.Value) error {
+	if !appendTime(b, v.Interface().(time.Time), e.layout) {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+type durationEncoder struct{}
+
+func (e *durationEncoder) encode(b array.Builder, v reflect.Value) error {
+	if !appendDuration(b, time.Duration(v.Int())) {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+type intervalEncoder struct{}
+
+func (e *intervalEncoder) encode(b array.Builder, v reflect.Value) error {
+	b.(*array.MonthDayNanoIntervalBuilder).Append(v.Interface().(arrow.MonthDayNanoInterval))
+	return nil
+}
+
+// decimalEncoder encodes DecimalMarshaler, big numbers and Go numbers into
+// decimal columns. Only floats are rounded to the column scale.
+type decimalEncoder struct{}
+
+func (e *decimalEncoder) encode(b array.Builder, v reflect.Value) error {
+	var (
+		r     *big.Rat
+		exact = true
+	)
+	switch x := addr(v).(type) {
+	case DecimalMarshaler:
+		unscaled, scale, err := x.MarshalDecimal()
+		if err != nil {
+			return errors.ErrMarshaler(v.Type(), err, "MarshalDecimal")
+		}
+		r = new(big.Rat).SetFrac(unscaled, pow10(scale))
+		r.Mul(r, new(big.Rat).SetInt(pow10(-scale)))
+	case *big.Int:
+		r = new(big.Rat).SetInt(x)
+	case *big.Rat:
+		r = x
+	case *big.Float:
+		r, _ = x.Rat(nil)
+	default:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			r = new(big.Rat).SetInt64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			r = new(big.Rat).SetInt(new(big.Int).SetUint64(v.Uint()))
+		default:
+			r, exact = new(big.Rat), false
+			if r.SetFloat64(v.Float()) == nil {
+				return valueError(b, v, "")
+			}
+		}
+	}
+	if r == nil || !appendDecimal(b, r, exact) {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+// extensionEncoder encodes values into the storage of extension columns.
+type extensionEncoder struct {
+	elem arrowEncoder
+}
+
+func (e *extensionEncoder) encode(b array.Builder, v reflect.Value) error {
+	return e.elem.encode(b.(*array.ExtensionBuilder).StorageBuilder(), v)
+}
+
+// registeredEncoder encodes values of registered extension types into the
+// storage of their columns.
+type registeredEncoder struct {
+	ext *decode.Extension
+}
+
+func (e *registeredEncoder) encode(b array.Builder, v reflect.Value) error {
+	if err := e.ext.Serialize(b, v.Interface()); err != nil {
+		return errors.ErrMarshaler(v.Type(), err, "Serialize")
+	}
+	return nil
+}
+
+// arrowMarshalerEncoder encodes ArrowMarshaler values.
+type arrowMarshalerEncoder struct{}
+
+func (e *arrowMarshalerEncoder) encode(b array.Builder, v reflect.Value) error {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	if err := addr(v).(ArrowMarshaler).MarshalArrow(b); err != nil {
+		return errors.ErrMarshaler(v.Type(), err, "MarshalArrow")
+	}
+	return nil
+}
+
+// marshalerEncoder encodes json.Marshaler and encoding.TextMarshaler values
+// into string columns, which the decoder passes back to their unmarshalers.
+type marshalerEncoder struct {
+	json bool
+}
+
+func (e *marshalerEncoder) encode(b array.Builder, v reflect.Value) error {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	var (
+		buf []byte
+		err error
+	)
+	if e.json {
+		buf, err = addr(v).(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return errors.ErrMarshaler(v.Type(), err, "MarshalJSON")
+		}
+	} else {
+		buf, err = addr(v).(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return errors.ErrMarshaler(v.Type(), err, "MarshalText")
+		}
+	}
+	if !appendBytes(b, buf) {
+		return valueError(b, v, "")
+	}
+	return nil
+}
+
+// addr returns a pointer to the value of v, whose method set includes the
+// methods of both receiver kinds.
+func addr(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		return v.Interface()
+	}
+	if v.CanAddr() {
+		return v.Addr().Interface()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p.Interface()
+}
+
+// arrowType returns the column type chosen by the ArrowTyper implementation
+// of t, nil when it has none.
+func arrowType(t reflect.Type) arrow.DataType {
+	if !implements(t, arrowTyperType) {
+		return nil
+	}
+	return reflect.New(t).Interface().(decode.ArrowTyper).ArrowType()
+}
+
+// implements reports whether t or a pointer to it implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(iface)
+}
+
+func isDecimalGoType(t reflect.Type) bool {
+	switch t {
+	case bigIntType, bigRatType, bigFloatType:
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return implements(t, marshalDecimalType)
+}
+
+func isDecimalType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.DECIMAL128, arrow.DECIMAL256:
+		return true
+	}
+	return false
+}
+
+func isUnionType(dt arrow.DataType) bool {
+	_, ok := dt.(arrow.UnionType)
+	return ok
+}
+
+func isTimeType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.TIMESTAMP, arrow.DATE32, arrow.DATE64, arrow.TIME32, arrow.TIME64,
+		arrow.STRING, arrow.LARGE_STRING, arrow.BINARY, arrow.LARGE_BINARY:
+		return true
+	}
+	return isIntegerType(dt)
+}
+
+func isDurationType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.DURATION, arrow.TIME32, arrow.TIME64:
+		return true
+	}
+	return false
+}
+
+func isIntegerType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return true
+	}
+	return false
+}
+
+func isFloatType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.FLOAT32, arrow.FLOAT64:
+		return true
+	}
+	return false
+}
+
+func isStringType(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.STRING, arrow.LARGE_STRING, arrow.BINARY, arrow.LARGE_BINARY, arrow.FIXED_SIZE_BINARY:
+		return true
+	}
+	return false
+}
+
+// listElemField returns the element field of the list type dt.
+func listElemField(dt arrow.DataType) (arrow.Field, bool) {
+	switch t := dt.(type) {
+	case *arrow.ListType:
+		return t.ElemField(), true
+	case *arrow.LargeListType:
+		return t.ElemField(), true
+	case *arrow.FixedSizeListType:
+		return t.ElemField(), true
+	}
+	return arrow.Field{}, false
+}
+
+// valueError reports a value of v that the column of b cannot hold.
+func valueError(b array.Builder, v reflect.Value, desc string) error {
+	err := errors.ErrArrowMarshalType("", b.Type(), v.Type(), -1)
+	if desc == "" && v.CanInterface() {
+		desc = strconv.Quote(fmtValue(v))
+	}
+	err.Value = desc
+	return err
+}
+
+func fmtValue(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case interface{ String() string }:
+		return x.String()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.String:
+		return v.String()
+	}
+	return v.Type().String()
+}
+
+func annotateRow(err error, row int) error {
+	if e, ok := err.(*errors.MarshalTypeError); ok && e.Row < 0 {
+		e.Row = row
+	}
+	return err
+}
+
+// annotateColumn prefixes the column path of a type error raised while
+// encoding a nested value with the name of the enclosing column.
+func annotateColumn(err error, name string) error {
+	if e, ok := err.(*errors.MarshalTypeError); ok {
+		switch {
+		case e.Column == "":
+			e.Column = name
+		case e.Column[0] == '[':
+			e.Column = name + e.Column
+		default:
+			e.Column = name + "." + e.Column
+		}
+	}
+	return err
+}
+
+// annotateElement prefixes the column path of a type error raised while
+// encoding a list element or map entry.
+func annotateElement(err error) error {
+	return annotateColumn(err, "[]")
+}
@@ -0,0 +1,178 @@
+package encode
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/decode"
+	"github.com/chronowave/client/go/internal/runtime"
+)
+
+var (
+	unmarshalJSONType    = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	unmarshalTextType    = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	unmarshalDecimalType = reflect.TypeOf((*decode.DecimalUnmarshaler)(nil)).Elem()
+	intervalType         = reflect.TypeOf(arrow.MonthDayNanoInterval{})
+)
+
+// Field is a struct field matched with the column of the same name.
+type Field struct {
+	Name      string              // column name, the json tag name or else the Go field name
+	Index     []int               // path from the struct as by reflect.Value.FieldByIndex
+	Tagged    bool                // whether Name comes from the json tag
+	Quoted    bool                // whether the json tag has the string option, for basic types only
+	OmitEmpty bool                // whether the json tag has the omitempty option
+	Aliases   []string            // alternate column names of the alias options of the json tag
+	Field     reflect.StructField // the Go field in its declaring struct
+}
+
+// TypeFields returns the fields of the struct type t matched with columns, in
+// declaration order. The fields of embedded structs are promoted by the rules
+// of encoding/json, as the decoder does: a shallower field hides deeper ones of
+// the same name, and of several fields at the same depth only a single tagged
+// one is kept, the fields of a struct embedded twice at a depth being dropped.
+// Embedded structs decoded through an unmarshaler are not
+// promoted but matched as one field named after their type.
+func TypeFields(t reflect.Type) []Field {
+	type embedded struct {
+		index []int
+		typ   reflect.Type
+	}
+	var (
+		fields  []Field
+		next    = []embedded{{typ: t}}
+		visited = map[reflect.Type]bool{}
+		// the number of times a struct type is embedded at the current and
+		// next depth
+		count, nextCount map[reflect.Type]int
+	)
+	for len(next) > 0 {
+		current := next
+		next = nil
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				if runtime.IsIgnoredStructField(sf) {
+					continue
+				}
+				tag := runtime.StructTagFromField(sf)
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				if ft := sf.Type; sf.Anonymous && !tag.IsTaggedKey {
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if isPromotedStruct(ft) {
+						nextCount[ft]++
+						if nextCount[ft] == 1 {
+							next = append(next, embedded{index: index, typ: ft})
+						}
+						continue
+					}
+				}
+				fields = append(fields, Field{
+					Name:      tag.Key,
+					Index:     index,
+					Tagged:    tag.IsTaggedKey,
+					Quoted:    tag.IsString && isQuotableType(sf.Type),
+					OmitEmpty: tag.IsOmitEmpty,
+					Aliases:   tag.Aliases,
+					Field:     sf,
+				})
+				if count[e.typ] > 1 {
+					// a struct embedded several times at the same depth
+					// hides its fields as a conflict does
+					fields = append(fields, fields[len(fields)-1])
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if len(a.Index) != len(b.Index) {
+			return len(a.Index) < len(b.Index)
+		}
+		return a.Tagged && !b.Tagged
+	})
+
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].Name == fields[i].Name {
+			j++
+		}
+		if f, ok := dominantField(fields[i:j]); ok {
+			dominant = append(dominant, f)
+		}
+		i = j
+	}
+
+	sort.Slice(dominant, func(i, j int) bool {
+		a, b := dominant[i].Index, dominant[j].Index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return dominant
+}
+
+// dominantField returns the field hiding the others of fields, which share a
+// name and are sorted by depth, tagged fields first.
+func dominantField(fields []Field) (Field, bool) {
+	if len(fields) > 1 && len(fields[0].Index) == len(fields[1].Index) && fields[0].Tagged == fields[1].Tagged {
+		return Field{}, false
+	}
+	return fields[0], true
+}
+
+// isQuotableType reports whether the string option of a json tag applies to
+// t, which encoding/json restricts to strings, numbers and booleans, and the
+// decoder to those without an unmarshaler.
+func isQuotableType(t reflect.Type) bool {
+	if t.Name() == "" && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isUnmarshaler(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// isPromotedStruct reports whether the fields of an embedded t are promoted,
+// the decoder keeping structs it decodes through an unmarshaler as one field.
+func isPromotedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == intervalType {
+		return false
+	}
+	return !isUnmarshaler(t)
+}
+
+// isUnmarshaler reports whether the decoder decodes t through an unmarshaler.
+func isUnmarshaler(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return p.Implements(unmarshalJSONType) || p.Implements(unmarshalTextType) || p.Implements(unmarshalDecimalType)
+}
@@ -0,0 +1,147 @@
+package encode
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+var (
+	netipAddrType    = reflect.TypeOf(netip.Addr{})
+	netipPrefixType  = reflect.TypeOf(netip.Prefix{})
+	hardwareAddrType = reflect.TypeOf(net.HardwareAddr{})
+)
+
+// compileBinary returns the encoder of the Go types stored in binary columns,
+// byte arrays and network addresses, into columns of type dt. ok is false
+// for the other pairs of types, left to their usual encoders.
+func compileBinary(t reflect.Type, dt arrow.DataType) (arrowEncoder, bool) {
+	if implements(t, marshalArrowType) {
+		return nil, false
+	}
+	fsb, isFixed := dt.(*arrow.FixedSizeBinaryType)
+	switch {
+	case t == netipAddrType:
+		if isFixed && (fsb.ByteWidth == 16 || fsb.ByteWidth == 4) {
+			return &addrEncoder{}, true
+		}
+		if isTextType(dt) {
+			return &netipStringEncoder{}, true
+		}
+	case t == netipPrefixType:
+		if isFixed && fsb.ByteWidth == decode.PrefixByteWidth {
+			return &prefixEncoder{}, true
+		}
+		if isTextType(dt) {
+			return &netipStringEncoder{}, true
+		}
+	case t == hardwareAddrType:
+		if isTextType(dt) {
+			return &hardwareAddrEncoder{}, true
+		}
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+		if isFixed && fsb.ByteWidth == t.Len() {
+			return &bytesEncoder{}, true
+		}
+		if t.Len() == 16 && isTextType(dt) && !implements(t, marshalJSONType) && !implements(t, marshalTextType) {
+			return &uuidStringEncoder{}, true
+		}
+	}
+	return nil, false
+}
+
+// isTextType reports whether dt is a string layout, binary ones excluded.
+func isTextType(dt arrow.DataType) bool {
+	return dt.ID() == arrow.STRING || dt.ID() == arrow.LARGE_STRING
+}
+
+// addrEncoder encodes netip.Addr into FixedSizeBinary(16) columns, IPv4
+// addresses mapped to IPv6, or into FixedSizeBinary(4) columns holding IPv4
+// addresses only. The zero Addr is null.
+type addrEncoder struct{}
+
+func (e *addrEncoder) encode(b array.Builder, v reflect.Value) error {
+	addr := v.Interface().(netip.Addr)
+	if !addr.IsValid() {
+		b.AppendNull()
+		return nil
+	}
+	fb := b.(*array.FixedSizeBinaryBuilder)
+	if fb.Type().(*arrow.FixedSizeBinaryType).ByteWidth == 16 {
+		buf := addr.As16()
+		fb.Append(buf[:])
+		return nil
+	}
+	if addr = addr.Unmap(); !addr.Is4() {
+		return valueError(b, v, "")
+	}
+	buf := addr.As4()
+	fb.Append(buf[:])
+	return nil
+}
+
+// prefixEncoder encodes netip.Prefix into FixedSizeBinary columns of
+// decode.PrefixByteWidth bytes, the 16 bytes of the address followed by the
+// prefix length. The zero Prefix is null.
+type prefixEncoder struct{}
+
+func (e *prefixEncoder) encode(b array.Builder, v reflect.Value) error {
+	prefix := v.Interface().(netip.Prefix)
+	if !prefix.IsValid() {
+		b.AppendNull()
+		return nil
+	}
+	var buf [decode.PrefixByteWidth]byte
+	addr := prefix.Addr().As16()
+	copy(buf[:], addr[:])
+	buf[16] = byte(prefix.Bits())
+	b.(*array.FixedSizeBinaryBuilder).Append(buf[:])
+	return nil
+}
+
+// netipStringEncoder encodes netip.Addr and netip.Prefix into string columns
+// in their text form. Zero values are null, rather than the empty string
+// MarshalText returns.
+type netipStringEncoder struct{}
+
+func (e *netipStringEncoder) encode(b array.Builder, v reflect.Value) error {
+	addr := v.Interface().(interface {
+		IsValid() bool
+		String() string
+	})
+	if !addr.IsValid() {
+		b.AppendNull()
+		return nil
+	}
+	appendString(b, addr.String())
+	return nil
+}
+
+// hardwareAddrEncoder encodes net.HardwareAddr into string columns in their
+// colon separated hexadecimal form, nil addresses as nulls.
+type hardwareAddrEncoder struct{}
+
+func (e *hardwareAddrEncoder) encode(b array.Builder, v reflect.Value) error {
+	if v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	appendString(b, v.Interface().(net.HardwareAddr).String())
+	return nil
+}
+
+// uuidStringEncoder encodes 16 byte arrays into string columns in the text
+// form of UUIDs.
+type uuidStringEncoder struct{}
+
+func (e *uuidStringEncoder) encode(b array.Builder, v reflect.Value) error {
+	var uuid [16]byte
+	reflect.Copy(reflect.ValueOf(uuid[:]), v)
+	appendString(b, decode.FormatUUID(uuid))
+	return nil
+}
@@ -0,0 +1,124 @@
+package encode
+
+import (
+	"reflect"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+
+	"github.com/chronowave/client/go/internal/decode"
+	"github.com/chronowave/client/go/internal/errors"
+)
+
+// unionChild is the encoder of the values of a union child.
+type unionChild struct {
+	id   int
+	code arrow.UnionTypeCode
+	name string
+	enc  arrowEncoder
+}
+
+// appendUnion appends v to the child c of the union builder b, and nulls to
+// the other children of sparse unions, whose children all have the length of
+// the union.
+func appendUnion(b array.Builder, c *unionChild, v reflect.Value) error {
+	ub := b.(*unionBuilder)
+	ub.Append(c.code)
+	if err := c.enc.encode(ub.Child(c.id), v); err != nil {
+		return annotateColumn(err, c.name)
+	}
+	if ub.Mode() == arrow.SparseMode {
+		for i := range ub.children {
+			if i != c.id {
+				ub.Child(i).AppendNull()
+			}
+		}
+	}
+	return nil
+}
+
+// compileOneof returns the encoder of the struct t into the union dt, each
+// field encoding into the child of its name. The fields are pointers, a zero
+// value being a value of its child.
+func compileOneof(t reflect.Type, dt arrow.UnionType) (arrowEncoder, error) {
+	goFields := TypeFields(t)
+	enc := &oneofEncoder{}
+	for i, f := range dt.Fields() {
+		goField, ok := lookupField(goFields, f.Name)
+		if !ok {
+			continue
+		}
+		if goField.Field.Type.Kind() != reflect.Ptr {
+			return nil, errors.ErrArrowMarshalType(f.Name, f.Type, goField.Field.Type, -1)
+		}
+		elem, err := compileField(goField, f)
+		if err != nil {
+			return nil, annotateColumn(err, f.Name)
+		}
+		enc.fields = append(enc.fields, oneofField{
+			index: goField.Index,
+			child: unionChild{id: i, code: dt.TypeCodes()[i], name: f.Name, enc: elem},
+		})
+	}
+	return enc, nil
+}
+
+type oneofField struct {
+	index []int
+	child unionChild
+}
+
+// oneofEncoder encodes structs with a pointer field per variant into unions:
+// the first non-nil field is the value, and structs without any are null.
+type oneofEncoder struct {
+	fields []oneofField
+}
+
+func (e *oneofEncoder) encode(b array.Builder, v reflect.Value) error {
+	for i := range e.fields {
+		f := &e.fields[i]
+		fv, ok := fieldByIndex(v, f.index)
+		if ok && !fv.IsNil() {
+			return appendUnion(b, &f.child, fv)
+		}
+	}
+	b.AppendNull()
+	return nil
+}
+
+// unionInterfaceEncoder encodes values of an interface type with registered
+// variants into unions, into the child of the type code of their variant.
+type unionInterfaceEncoder struct {
+	dt       arrow.UnionType
+	variants map[reflect.Type]*unionChild
+}
+
+func compileUnionInterface(t reflect.Type, dt arrow.UnionType, variants map[arrow.UnionTypeCode]reflect.Type) (arrowEncoder, error) {
+	enc := &unionInterfaceEncoder{dt: dt, variants: make(map[reflect.Type]*unionChild, len(variants))}
+	for i, f := range dt.Fields() {
+		code := dt.TypeCodes()[i]
+		vt, ok := variants[code]
+		if !ok {
+			continue
+		}
+		elem, err := compile(vt, f.Type, decode.FieldLayout(f, ""))
+		if err != nil {
+			return nil, annotateColumn(err, f.Name)
+		}
+		enc.variants[vt] = &unionChild{id: i, code: code, name: f.Name, enc: elem}
+	}
+	return enc, nil
+}
+
+func (e *unionInterfaceEncoder) encode(b array.Builder, v reflect.Value) error {
+	if v.IsNil() {
+		b.AppendNull()
+		return nil
+	}
+	v = v.Elem()
+	c, ok := e.variants[v.Type()]
+	if !ok {
+		return errors.ErrArrowMarshalType("", e.dt, v.Type(), -1)
+	}
+	return appendUnion(b, c, v)
+}
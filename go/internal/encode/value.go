@@ -0,0 +1,266 @@
+package encode
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/decimal128"
+	"github.com/apache/arrow/go/v10/arrow/decimal256"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+// appendInt appends v to an integer or float builder, reporting false when
+// the column cannot hold it.
+func appendInt(b array.Builder, v int64) bool {
+	switch b := b.(type) {
+	case *array.Int8Builder:
+		if v < math.MinInt8 || v > math.MaxInt8 {
+			return false
+		}
+		b.Append(int8(v))
+	case *array.Int16Builder:
+		if v < math.MinInt16 || v > math.MaxInt16 {
+			return false
+		}
+		b.Append(int16(v))
+	case *array.Int32Builder:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return false
+		}
+		b.Append(int32(v))
+	case *array.Int64Builder:
+		b.Append(v)
+	case *array.Float32Builder:
+		b.Append(float32(v))
+	case *array.Float64Builder:
+		b.Append(float64(v))
+	default:
+		if v < 0 {
+			return false
+		}
+		return appendUint(b, uint64(v), 64)
+	}
+	return true
+}
+
+// appendUint appends v, a Go value of the given bit size, to an integer or
+// float builder, reporting false when the column cannot hold it. Signed
+// columns of the same bit size hold the two's complement bits of values
+// beyond their range, DeriveArrowSchema mapping unsigned types onto them.
+func appendUint(b array.Builder, v uint64, bits int) bool {
+	switch b := b.(type) {
+	case *array.Uint8Builder:
+		if v > math.MaxUint8 {
+			return false
+		}
+		b.Append(uint8(v))
+	case *array.Uint16Builder:
+		if v > math.MaxUint16 {
+			return false
+		}
+		b.Append(uint16(v))
+	case *array.Uint32Builder:
+		if v > math.MaxUint32 {
+			return false
+		}
+		b.Append(uint32(v))
+	case *array.Uint64Builder:
+		b.Append(v)
+	case *array.Int8Builder:
+		if v > math.MaxInt8 && (bits != 8 || v > math.MaxUint8) {
+			return false
+		}
+		b.Append(int8(v))
+	case *array.Int16Builder:
+		if v > math.MaxInt16 && (bits != 16 || v > math.MaxUint16) {
+			return false
+		}
+		b.Append(int16(v))
+	case *array.Int32Builder:
+		if v > math.MaxInt32 && (bits != 32 || v > math.MaxUint32) {
+			return false
+		}
+		b.Append(int32(v))
+	case *array.Int64Builder:
+		if v > math.MaxInt64 && bits != 64 {
+			return false
+		}
+		b.Append(int64(v))
+	case *array.Float32Builder:
+		b.Append(float32(v))
+	case *array.Float64Builder:
+		b.Append(float64(v))
+	default:
+		return false
+	}
+	return true
+}
+
+// appendFloat appends v to a float builder.
+func appendFloat(b array.Builder, v float64) bool {
+	switch b := b.(type) {
+	case *array.Float32Builder:
+		b.Append(float32(v))
+	case *array.Float64Builder:
+		b.Append(v)
+	default:
+		return false
+	}
+	return true
+}
+
+// appendString appends v to a string or binary builder, reporting false when
+// v does not have the width of a fixed size binary column.
+func appendString(b array.Builder, v string) bool {
+	switch b := b.(type) {
+	case *array.StringBuilder:
+		b.Append(v)
+	case *array.LargeStringBuilder:
+		b.Append(v)
+	case *array.BinaryBuilder:
+		b.AppendString(v)
+	case *array.FixedSizeBinaryBuilder:
+		if len(v) != b.Type().(*arrow.FixedSizeBinaryType).ByteWidth {
+			return false
+		}
+		b.Append([]byte(v))
+	default:
+		return false
+	}
+	return true
+}
+
+// appendBytes is appendString for byte slices.
+func appendBytes(b array.Builder, v []byte) bool {
+	switch b := b.(type) {
+	case *array.BinaryBuilder:
+		b.Append(v)
+	case *array.FixedSizeBinaryBuilder:
+		if len(v) != b.Type().(*arrow.FixedSizeBinaryType).ByteWidth {
+			return false
+		}
+		b.Append(v)
+	default:
+		return appendString(b, string(v))
+	}
+	return true
+}
+
+// appendTime appends t to a timestamp, date or time of day builder, or
+// formatted with layout to a string or integer builder. Dates and times of
+// day are those of the wall clock of t.
+func appendTime(b array.Builder, t time.Time, layout decode.TimeLayout) bool {
+	switch b := b.(type) {
+	case *array.TimestampBuilder:
+		b.Append(arrow.Timestamp(epochCount(t, b.Type().(*arrow.TimestampType).Unit)))
+	case *array.Date32Builder:
+		b.Append(arrow.Date32(wallDate(t).Unix() / 86400))
+	case *array.Date64Builder:
+		b.Append(arrow.Date64(wallDate(t).UnixMilli()))
+	case *array.Time32Builder:
+		unit := b.Type().(*arrow.Time32Type).Unit
+		b.Append(arrow.Time32(timeOfDay(t) / unit.Multiplier()))
+	case *array.Time64Builder:
+		unit := b.Type().(*arrow.Time64Type).Unit
+		b.Append(arrow.Time64(timeOfDay(t) / unit.Multiplier()))
+	case *array.StringBuilder, *array.LargeStringBuilder, *array.BinaryBuilder:
+		return appendString(b, layout.FormatString(t))
+	default:
+		return appendInt(b, layout.FormatInt(t))
+	}
+	return true
+}
+
+// appendDuration appends d to a duration or time of day builder, truncated to its unit.
+func appendDuration(b array.Builder, d time.Duration) bool {
+	switch b := b.(type) {
+	case *array.DurationBuilder:
+		b.Append(arrow.Duration(d / b.Type().(*arrow.DurationType).Unit.Multiplier()))
+	case *array.Time32Builder:
+		b.Append(arrow.Time32(d / b.Type().(*arrow.Time32Type).Unit.Multiplier()))
+	case *array.Time64Builder:
+		b.Append(arrow.Time64(d / b.Type().(*arrow.Time64Type).Unit.Multiplier()))
+	default:
+		return false
+	}
+	return true
+}
+
+// appendDecimal appends v to a decimal builder, reporting false when v has
+// more fractional digits than the column scale or overflows its precision.
+// Inexact values, such as those of floats, are rounded to the scale.
+func appendDecimal(b array.Builder, v *big.Rat, exact bool) bool {
+	var precision, scale int32
+	switch dt := b.Type().(type) {
+	case *arrow.Decimal128Type:
+		precision, scale = dt.Precision, dt.Scale
+	case *arrow.Decimal256Type:
+		precision, scale = dt.Precision, dt.Scale
+	default:
+		return false
+	}
+
+	scaled := new(big.Rat).Mul(v, new(big.Rat).SetFrac(pow10(scale), pow10(-scale)))
+	unscaled := new(big.Int)
+	if scaled.IsInt() {
+		unscaled.Set(scaled.Num())
+	} else if exact {
+		return false
+	} else {
+		// round half away from zero
+		q, r := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+		if new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(scaled.Denom()) >= 0 {
+			q.Add(q, big.NewInt(int64(scaled.Sign())))
+		}
+		unscaled = q
+	}
+	if new(big.Int).Abs(unscaled).Cmp(pow10(precision)) >= 0 {
+		return false
+	}
+
+	switch b := b.(type) {
+	case *array.Decimal128Builder:
+		b.Append(decimal128.FromBigInt(unscaled))
+	case *array.Decimal256Builder:
+		b.Append(decimal256.FromBigInt(unscaled))
+	}
+	return true
+}
+
+// epochCount returns t as a count of unit since the Unix epoch.
+func epochCount(t time.Time, unit arrow.TimeUnit) int64 {
+	switch unit {
+	case arrow.Second:
+		return t.Unix()
+	case arrow.Millisecond:
+		return t.UnixMilli()
+	case arrow.Microsecond:
+		return t.UnixMicro()
+	}
+	return t.UnixNano()
+}
+
+// wallDate returns midnight UTC of the date of t in its location.
+func wallDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// timeOfDay returns the time elapsed since midnight of t in its location.
+func timeOfDay(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// pow10 returns 10^n for n > 0, 1 otherwise.
+func pow10(n int32) *big.Int {
+	if n <= 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+
+	"github.com/apache/arrow/go/v10/arrow"
 )
 
 type InvalidUTF8Error struct {
@@ -15,18 +17,23 @@ func (e *InvalidUTF8Error) Error() string {
 }
 
 type InvalidUnmarshalError struct {
-	Type reflect.Type
+	Type       reflect.Type
+	sourceFunc string
 }
 
 func (e *InvalidUnmarshalError) Error() string {
+	srcFunc := e.sourceFunc
+	if srcFunc == "" {
+		srcFunc = "json: Unmarshal"
+	}
 	if e.Type == nil {
-		return "json: Unmarshal(nil)"
+		return fmt.Sprintf("%s(nil)", srcFunc)
 	}
 
 	if e.Type.Kind() != reflect.Ptr {
-		return fmt.Sprintf("json: Unmarshal(non-pointer %s)", e.Type)
+		return fmt.Sprintf("%s(non-pointer %s)", srcFunc, e.Type)
 	}
-	return fmt.Sprintf("json: Unmarshal(nil %s)", e.Type)
+	return fmt.Sprintf("%s(nil %s)", srcFunc, e.Type)
 }
 
 // A MarshalerError represents an error from calling a MarshalJSON or MarshalText method.
@@ -71,17 +78,23 @@ func (e *UnmarshalFieldError) Error() string {
 	)
 }
 
-// An UnmarshalTypeError describes a JSON value that was
+// An UnmarshalTypeError describes a JSON value or an Arrow column that was
 // not appropriate for a value of a specific Go type.
 type UnmarshalTypeError struct {
-	Value  string       // description of JSON value - "bool", "array", "number -5"
-	Type   reflect.Type // type of Go value it could not be assigned to
-	Offset int64        // error occurred after reading Offset bytes
-	Struct string       // name of the struct type containing the field
-	Field  string       // the full path from root node to the field
+	Value     string         // description of JSON value - "bool", "array", "number -5"
+	Type      reflect.Type   // type of Go value it could not be assigned to
+	Offset    int64          // error occurred after reading Offset bytes
+	Struct    string         // name of the struct type containing the field
+	Field     string         // the full path from root node to the field
+	Column    string         // path of the Arrow column - "spans[].status"
+	ArrowType arrow.DataType // type of the Arrow column, nil for JSON values
+	Row       int            // row index in the record, -1 if detected from the schema
 }
 
 func (e *UnmarshalTypeError) Error() string {
+	if e.ArrowType != nil {
+		return e.arrowError()
+	}
 	if e.Struct != "" || e.Field != "" {
 		return fmt.Sprintf("json: cannot unmarshal %s into Go struct field %s.%s of type %s",
 			e.Value, e.Struct, e.Field, e.Type,
@@ -90,6 +103,85 @@ func (e *UnmarshalTypeError) Error() string {
 	return fmt.Sprintf("json: cannot unmarshal %s into Go value of type %s", e.Value, e.Type)
 }
 
+func (e *UnmarshalTypeError) arrowError() string {
+	value := "column"
+	if e.Value == "null" {
+		value = "null of column"
+	}
+	var msg string
+	if e.Struct != "" || e.Field != "" {
+		msg = fmt.Sprintf("arrow: cannot unmarshal %s %s of type %s into Go struct field %s.%s of type %s",
+			value, e.Column, e.ArrowType, e.Struct, e.Field, e.Type,
+		)
+	} else {
+		msg = fmt.Sprintf("arrow: cannot unmarshal %s %s of type %s into Go value of type %s",
+			value, e.Column, e.ArrowType, e.Type,
+		)
+	}
+	if e.Row >= 0 {
+		msg = fmt.Sprintf("%s at row %d", msg, e.Row)
+	}
+	return msg
+}
+
+// An UnknownColumnError describes a record column matching no field of a Go
+// struct, rejected when decoding disallows unknown columns.
+type UnknownColumnError struct {
+	Column string // path of the Arrow column - "spans[].status"
+	Struct string // name of the struct type
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("arrow: unknown column %s for Go struct %s", e.Column, e.Struct)
+}
+
+// A MissingColumnError describes a Go struct field without a record column,
+// rejected when decoding requires all fields.
+type MissingColumnError struct {
+	Column string // path of the missing Arrow column - "spans[].status"
+	Struct string // name of the struct type containing the field
+	Field  string // the field
+}
+
+func (e *MissingColumnError) Error() string {
+	return fmt.Sprintf("arrow: missing column %s for Go struct field %s.%s", e.Column, e.Struct, e.Field)
+}
+
+// A MarshalTypeError describes a Go value that cannot be encoded into the
+// Arrow column it is matched with.
+type MarshalTypeError struct {
+	Value     string         // the rejected value, empty when every value of Type is
+	Type      reflect.Type   // type of the Go value
+	Column    string         // path of the Arrow column - "spans[].status"
+	ArrowType arrow.DataType // type of the Arrow column
+	Row       int            // index of the encoded value, -1 if detected from the schema
+}
+
+func (e *MarshalTypeError) Error() string {
+	msg := fmt.Sprintf("arrow: cannot marshal Go value of type %s into column %s of type %s",
+		e.Type, e.Column, e.ArrowType,
+	)
+	if e.Value != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Value)
+	}
+	if e.Row >= 0 {
+		msg = fmt.Sprintf("%s at row %d", msg, e.Row)
+	}
+	return msg
+}
+
+// An InvalidMarshalError describes an invalid argument passed to MarshalRecord.
+type InvalidMarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidMarshalError) Error() string {
+	if e.Type == nil {
+		return "arrow: MarshalRecord(nil)"
+	}
+	return fmt.Sprintf("arrow: MarshalRecord(non-slice %s)", e.Type)
+}
+
 // An UnsupportedTypeError is returned by Marshal when attempting
 // to encode an unsupported value type.
 type UnsupportedTypeError struct {
@@ -113,6 +205,64 @@ func ErrSyntax(msg string, offset int64) *SyntaxError {
 	return &SyntaxError{msg: msg, Offset: offset}
 }
 
+func ErrInvalidRecordUnmarshal(typ reflect.Type) *InvalidUnmarshalError {
+	return &InvalidUnmarshalError{
+		Type:       typ,
+		sourceFunc: "arrow: UnmarshalRecord",
+	}
+}
+
+func ErrInvalidColumnsUnmarshal(typ reflect.Type) *InvalidUnmarshalError {
+	return &InvalidUnmarshalError{
+		Type:       typ,
+		sourceFunc: "arrow: UnmarshalColumns",
+	}
+}
+
+func ErrInvalidExtract(typ reflect.Type) *InvalidUnmarshalError {
+	return &InvalidUnmarshalError{
+		Type:       typ,
+		sourceFunc: "arrow: Extract",
+	}
+}
+
+func ErrInvalidRecordMarshal(typ reflect.Type) *InvalidMarshalError {
+	return &InvalidMarshalError{Type: typ}
+}
+
+func ErrArrowType(column string, arrowType arrow.DataType, typ reflect.Type, row int) *UnmarshalTypeError {
+	return &UnmarshalTypeError{
+		Value:     arrowType.String(),
+		Type:      typ,
+		Column:    column,
+		ArrowType: arrowType,
+		Row:       row,
+	}
+}
+
+func ErrArrowNull(column string, arrowType arrow.DataType, typ reflect.Type, row int) *UnmarshalTypeError {
+	e := ErrArrowType(column, arrowType, typ, row)
+	e.Value = "null"
+	return e
+}
+
+func ErrUnknownColumn(column, structName string) *UnknownColumnError {
+	return &UnknownColumnError{Column: column, Struct: structName}
+}
+
+func ErrMissingColumn(column, structName, field string) *MissingColumnError {
+	return &MissingColumnError{Column: column, Struct: structName, Field: field}
+}
+
+func ErrArrowMarshalType(column string, arrowType arrow.DataType, typ reflect.Type, row int) *MarshalTypeError {
+	return &MarshalTypeError{
+		Type:      typ,
+		Column:    column,
+		ArrowType: arrowType,
+		Row:       row,
+	}
+}
+
 func ErrMarshaler(typ reflect.Type, err error, msg string) *MarshalerError {
 	return &MarshalerError{
 		Type:       typ,
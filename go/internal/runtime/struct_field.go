@@ -34,6 +34,7 @@ type StructTag struct {
 	IsTaggedKey bool
 	IsOmitEmpty bool
 	IsString    bool
+	Aliases     []string // alternate column names of the alias=name options
 	Field       reflect.StructField
 }
 
@@ -54,7 +55,7 @@ func isValidTag(s string) bool {
 	}
 	for _, c := range s {
 		switch {
-		case strings.ContainsRune("!#$%&()*+-./:<=>?@[]^_{|}~ ", c):
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
 			// Backslash and quote chars are reserved, but
 			// otherwise any punctuation chars are allowed
 			// in a tag name.
@@ -84,6 +85,10 @@ func StructTagFromField(field reflect.StructField) *StructTag {
 				st.IsOmitEmpty = true
 			case "string":
 				st.IsString = true
+			default:
+				if alias := strings.TrimPrefix(opt, "alias="); alias != opt && isValidTag(alias) {
+					st.Aliases = append(st.Aliases, alias)
+				}
 			}
 		}
 	}
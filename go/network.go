@@ -0,0 +1,51 @@
+package client
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+var (
+	netipAddrType    = reflect.TypeOf(netip.Addr{})
+	netipPrefixType  = reflect.TypeOf(netip.Prefix{})
+	hardwareAddrType = reflect.TypeOf(net.HardwareAddr{})
+)
+
+// binaryArrowType returns the column type of UUIDs and network addresses,
+// nil for other types. netip.Addr is stored as FixedSizeBinary(16), IPv4
+// addresses mapped to IPv6, netip.Prefix as FixedSizeBinary(17), the address
+// followed by the prefix length, net.HardwareAddr as Binary and UUIDs with
+// the UUIDType extension type. The string tag stores all of them as text.
+func binaryArrowType(t reflect.Type, opts arrowTagOptions) arrow.DataType {
+	var dt arrow.DataType
+	switch {
+	case t == netipAddrType:
+		dt = &arrow.FixedSizeBinaryType{ByteWidth: 16}
+	case t == netipPrefixType:
+		dt = &arrow.FixedSizeBinaryType{ByteWidth: decode.PrefixByteWidth}
+	case t == hardwareAddrType:
+		dt = arrow.BinaryTypes.Binary
+	case isUUIDType(t):
+		dt = NewUUIDType()
+	default:
+		return nil
+	}
+	if opts.kind == "string" {
+		return arrow.BinaryTypes.String
+	}
+	return dt
+}
+
+// isUUIDType reports whether t is [16]byte or a 16 byte array type named
+// UUID, as the types of the common UUID packages are.
+func isUUIDType(t reflect.Type) bool {
+	if t.Kind() != reflect.Array || t.Len() != 16 || t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	return t.Name() == "" || t.Name() == "UUID"
+}
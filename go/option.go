@@ -0,0 +1,222 @@
+package client
+
+import (
+	"runtime"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+// DecodeOption holds the settings of UnmarshalRecordWithOptions and UnmarshalColumns.
+type DecodeOption = decode.Option
+
+// DecodeOptionFunc changes a DecodeOption.
+type DecodeOptionFunc func(*DecodeOption)
+
+// TimeLocation selects the location of time.Time values decoded from timestamp columns.
+type TimeLocation = decode.TimeLocation
+
+const (
+	// ColumnTimeLocation uses the time zone of the column, UTC when it has none.
+	ColumnTimeLocation = decode.ColumnTimeLocation
+	// UTCTimeLocation decodes every timestamp in UTC.
+	UTCTimeLocation = decode.UTCTimeLocation
+	// LocalTimeLocation decodes every timestamp in time.Local.
+	LocalTimeLocation = decode.LocalTimeLocation
+)
+
+// DecodeTimeLocation decodes timestamps in the location selected by loc.
+// Column time zones are resolved once per record schema, and an invalid
+// one fails decoding whatever loc selects.
+func DecodeTimeLocation(loc TimeLocation) DecodeOptionFunc {
+	return func(opt *DecodeOption) {
+		opt.TimeLocation = loc
+	}
+}
+
+// DisallowUnknownColumns fails decoding records with a column, or a child of a
+// struct column, that matches no Go struct field.
+func DisallowUnknownColumns() DecodeOptionFunc {
+	return func(opt *DecodeOption) {
+		opt.Flags |= decode.DisallowUnknownColumnsOption
+	}
+}
+
+// DecodeZeroCopy decodes string columns into strings, and string and binary
+// columns into byte slices, aliasing the record buffers rather than copies of
+// them. The values are only valid while the record is retained, as
+// UnmarshalRecordZeroCopy and QueryZeroCopy do until the RecordRef they
+// return is released, and the byte slices must not be modified.
+func DecodeZeroCopy() DecodeOptionFunc {
+	return func(opt *DecodeOption) {
+		opt.Flags |= decode.ZeroCopyOption
+	}
+}
+
+// RequireAllFields fails decoding records without the column of a Go struct
+// field, including the fields of structs decoded from struct columns.
+func RequireAllFields() DecodeOptionFunc {
+	return func(opt *DecodeOption) {
+		opt.Flags |= decode.RequireAllFieldsOption
+	}
+}
+
+// QueryOption holds the settings of the queries of a Client.
+type QueryOption struct {
+	// ProjectColumns requests only the columns decoded into Go struct fields.
+	ProjectColumns bool
+}
+
+// QueryOptionFunc changes a QueryOption.
+type QueryOptionFunc func(*QueryOption)
+
+// ProjectColumns makes Query and QueryZeroCopy request only the columns the
+// fields of the Go struct rows are decoded into, rather than the whole
+// documents found. The columns are matched with the fields as when decoding,
+// against the schema of the flight queried, which costs a GetSchema call per
+// query. The query is rewritten to find one variable per column, named after
+// it and bound to it in the documents of the variable it found. Queries
+// without a FROM clause, finding aggregates or several variables, and
+// columns which are not SSQL names are sent as they are.
+func ProjectColumns() QueryOptionFunc {
+	return func(opt *QueryOption) {
+		opt.ProjectColumns = true
+	}
+}
+
+// NullPolicy selects how nulls decode into Go struct fields other than
+// pointers, slices, maps and interfaces.
+type NullPolicy = decode.NullPolicy
+
+const (
+	// ZeroNullPolicy sets the field to its zero value.
+	ZeroNullPolicy = decode.ZeroNullPolicy
+	// KeepNullPolicy keeps the value the field holds, which is only set
+	// before decoding for rows decoded with ReuseSliceMode.
+	KeepNullPolicy = decode.KeepNullPolicy
+	// ErrorNullPolicy fails decoding with an *UnmarshalTypeError.
+	ErrorNullPolicy = decode.ErrorNullPolicy
+)
+
+// DecodeNullPolicy decodes nulls into Go struct fields which cannot be nil
+// as selected by policy.
+func DecodeNullPolicy(policy NullPolicy) DecodeOptionFunc {
+	return func(opt *DecodeOption) {
+		opt.NullPolicy = policy
+	}
+}
+
+// SliceMode selects how UnmarshalRecordWithOptions and UnmarshalColumns fill
+// the slices they decode rows into.
+type SliceMode = decode.SliceMode
+
+const (
+	// ReplaceSliceMode replaces the slice with a new one holding the rows.
+	ReplaceSliceMode = decode.ReplaceSliceMode
+	// AppendSliceMode appends the rows to the slice, in its spare capacity
+	// when it is large enough.
+	AppendSliceMode = decode.AppendSliceMode
+	// ReuseSliceMode decodes the rows into the elements of the slice when
+	// its capacity is large enough, reset to their zero value. The values
+	// pointer elements point to are reset and reused, so the caller must not
+	// keep them across calls. With KeepNullPolicy the elements are not reset,
+	// so nulls keep the values decoded into them before.
+	ReuseSliceMode = decode.ReuseSliceMode
+)
+
+// DecodeSliceMode fills slices as selected by mode. Decoding records
+// repeatedly into the same slice with AppendSliceMode or ReuseSliceMode
+// spares allocating a slice per record.
+func DecodeSliceMode(mode SliceMode) DecodeOptionFunc {
+	return func(opt *DecodeOption) {
+		opt.SliceMode = mode
+	}
+}
+
+// DecodeParallel decodes the rows of large records with workers goroutines,
+// GOMAXPROCS ones when workers is 0 or less, each one decoding chunks of
+// chunkRows rows into their part of the slice. chunkRows 0 selects a size from
+// the number of rows and workers. Values decoded and errors returned are the
+// same as decoding the rows on the calling goroutine: once a chunk fails, the
+// rows are decoded again there to report the error it would.
+func DecodeParallel(workers, chunkRows int) DecodeOptionFunc {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return func(opt *DecodeOption) {
+		opt.Workers = workers
+		opt.ChunkRows = chunkRows
+	}
+}
+
+func newDecodeOption(optFuncs []DecodeOptionFunc) *DecodeOption {
+	opt := &DecodeOption{}
+	for _, optFunc := range optFuncs {
+		optFunc(opt)
+	}
+	return opt
+}
+
+// SchemaOption holds the settings of DeriveArrowSchemaWithOptions.
+type SchemaOption struct {
+	// MaxDepth is the number of struct levels a recursive type is unrolled
+	// to, 0 for failing on recursive types.
+	MaxDepth int
+}
+
+// SchemaOptionFunc changes a SchemaOption.
+type SchemaOptionFunc func(*SchemaOption)
+
+// SchemaMaxDepth unrolls recursive types, such as a span holding its child
+// spans, into depth levels of nested struct columns. The values nested
+// deeper are stored as JSON text in string columns with the "json"
+// "chronowave.client.encoding" metadata, which UnmarshalRecord and
+// MarshalRecord decode and encode.
+func SchemaMaxDepth(depth int) SchemaOptionFunc {
+	return func(opt *SchemaOption) {
+		opt.MaxDepth = depth
+	}
+}
+
+func newSchemaOption(optFuncs []SchemaOptionFunc) *SchemaOption {
+	opt := &SchemaOption{}
+	for _, optFunc := range optFuncs {
+		optFunc(opt)
+	}
+	return opt
+}
+
+// InferOption holds the settings of InferSchemaFromJSON.
+type InferOption struct {
+	// SampleSize is the number of documents read, 0 for all of them.
+	SampleSize int
+	// DateFormat maps column names to the format of their times, as for
+	// DeriveArrowSchema. Other string columns are times when every value
+	// has the RFC 3339 layout.
+	DateFormat map[string]DateFormat
+}
+
+// InferOptionFunc changes an InferOption.
+type InferOptionFunc func(*InferOption)
+
+// InferSampleSize reads at most n documents to infer a schema.
+func InferSampleSize(n int) InferOptionFunc {
+	return func(opt *InferOption) {
+		opt.SampleSize = n
+	}
+}
+
+// InferDateFormat infers time columns from their values in format, keyed by
+// column name.
+func InferDateFormat(format map[string]DateFormat) InferOptionFunc {
+	return func(opt *InferOption) {
+		opt.DateFormat = format
+	}
+}
+
+func newInferOption(optFuncs []InferOptionFunc) *InferOption {
+	opt := &InferOption{}
+	for _, optFunc := range optFuncs {
+		optFunc(opt)
+	}
+	return opt
+}
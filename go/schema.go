@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/memory"
 
 	"github.com/chronowave/client/go/internal/decode"
+	"github.com/chronowave/client/go/internal/encode"
+	"github.com/chronowave/client/go/internal/errors"
 	"github.com/chronowave/fbs/go"
 )
 
@@ -23,13 +27,137 @@ func EmptyDateFormat() map[string]DateFormat {
 	return make(map[string]DateFormat)
 }
 
-var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+// defaultDateFormat is the format of times without a DateFormat.
+var defaultDateFormat = DateFormat{TimeUnit: arrow.Millisecond}
 
+// dateFormatArrowType returns the column type of times in format tf and the
+// metadata recording its layout.
+func dateFormatArrowType(tf DateFormat) (arrow.DataType, arrow.Metadata) {
+	layout := time.RFC3339Nano
+	if len(tf.Layout) > 0 {
+		layout = tf.Layout
+	}
+	if tf.Is32Bits {
+		return &arrow.Date32Type{}, layoutMetadata(layout)
+	}
+	return &arrow.TimestampType{Unit: tf.TimeUnit, TimeZone: tf.TimeZone}, layoutMetadata(layout)
+}
+
+var (
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	arrowTyperType = reflect.TypeOf((*ArrowTyper)(nil)).Elem()
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	intervalType   = reflect.TypeOf(arrow.MonthDayNanoInterval{})
+)
+
+// InvalidUnmarshalError describes an invalid argument passed to UnmarshalRecord.
+type InvalidUnmarshalError = errors.InvalidUnmarshalError
+
+// UnmarshalTypeError describes an Arrow column that cannot be decoded into the
+// Go value it is matched with. Column, ArrowType and Row locate the mismatch.
+type UnmarshalTypeError = errors.UnmarshalTypeError
+
+// UnknownColumnError describes a record column matching no Go struct field,
+// rejected by DisallowUnknownColumns.
+type UnknownColumnError = errors.UnknownColumnError
+
+// MissingColumnError describes a Go struct field without a record column,
+// rejected by RequireAllFields.
+type MissingColumnError = errors.MissingColumnError
+
+// InvalidMarshalError describes an invalid argument passed to MarshalRecord.
+type InvalidMarshalError = errors.InvalidMarshalError
+
+// MarshalTypeError describes a Go value that cannot be encoded into the Arrow
+// column it is matched with.
+type MarshalTypeError = errors.MarshalTypeError
+
+// ArrowUnmarshaler is implemented by types that decode themselves from the
+// i-th value of an arrow array, which is never null. It takes precedence
+// over json.Unmarshaler and encoding.TextUnmarshaler.
+type ArrowUnmarshaler = decode.ArrowUnmarshaler
+
+// ArrowMarshaler is implemented by types that encode themselves by appending
+// one value to the builder of their column.
+type ArrowMarshaler = encode.ArrowMarshaler
+
+// ArrowTyper is implemented by types that choose the arrow type of their
+// column, as DeriveArrowSchema derives it. Columns of another type fail to
+// decode into them or encode from them.
+type ArrowTyper = decode.ArrowTyper
+
+// Row is a view of one row of a record, read without decoding it into a Go value.
+type Row = decode.Row
+
+// Rows returns a view of every row of record. The rows are only valid while
+// the record is retained.
+func Rows(record arrow.Record) []Row {
+	return decode.Rows(record)
+}
+
+// UnmarshalRecord decodes record into the slice pointed to by v, one element
+// per row. Columns are matched with struct fields by json tag name or else
+// field name, then by the names of the alias options of json tags, such as
+// `json:"status,alias=http_status"`, then case-insensitively.
 func UnmarshalRecord(record arrow.Record, v any) error {
-	return decode.Unmarshal(record, v)
+	return decode.Unmarshal(record, v, &decode.Option{})
+}
+
+// UnmarshalRecordWithOptions is UnmarshalRecord configured by optFuncs.
+func UnmarshalRecordWithOptions(record arrow.Record, v any, optFuncs ...DecodeOptionFunc) error {
+	return decode.Unmarshal(record, v, newDecodeOption(optFuncs))
+}
+
+// RecordRef keeps a record alive while values decoded from it with
+// DecodeZeroCopy are in use.
+type RecordRef struct {
+	record   arrow.Record
+	released atomic.Bool
+}
+
+// Release releases the record, after which the strings decoded from it must
+// no longer be used. Releasing a RecordRef more than once has no effect.
+func (r *RecordRef) Release() {
+	if r.record != nil && r.released.CompareAndSwap(false, true) {
+		r.record.Release()
+	}
+}
+
+// UnmarshalRecordZeroCopy is UnmarshalRecordWithOptions with DecodeZeroCopy,
+// strings and byte slices decoded from record aliasing its buffers. It retains record until
+// the returned RecordRef is released.
+func UnmarshalRecordZeroCopy(record arrow.Record, v any, optFuncs ...DecodeOptionFunc) (*RecordRef, error) {
+	opt := newDecodeOption(optFuncs)
+	opt.Flags |= decode.ZeroCopyOption
+	if err := decode.Unmarshal(record, v, opt); err != nil {
+		return nil, err
+	}
+	record.Retain()
+	return &RecordRef{record: record}, nil
+}
+
+// UnmarshalColumns decodes record into the struct pointed to by v, whose
+// fields are slices filled with the whole column of the matching name.
+func UnmarshalColumns(record arrow.Record, v any, optFuncs ...DecodeOptionFunc) error {
+	return decode.UnmarshalColumns(record, v, newDecodeOption(optFuncs))
+}
+
+// MarshalRecord encodes v, a slice of structs or of maps with string keys,
+// into a record of schema, one row per element. Fields are matched with
+// columns by name as UnmarshalRecord does, and columns without a matching
+// field are null. Times are formatted into string and integer columns with
+// the LAYOUT metadata of their field. The caller releases the record.
+func MarshalRecord(v any, schema *arrow.Schema) (arrow.Record, error) {
+	return encode.Marshal(memory.DefaultAllocator, schema, v)
 }
 
 func DeriveArrowSchema(obj any, format map[string]DateFormat) (*arrow.Schema, error) {
+	return DeriveArrowSchemaWithOptions(obj, format)
+}
+
+// DeriveArrowSchemaWithOptions is DeriveArrowSchema configured by optFuncs.
+func DeriveArrowSchemaWithOptions(obj any, format map[string]DateFormat, optFuncs ...SchemaOptionFunc) (*arrow.Schema, error) {
 	if format == nil {
 		format = EmptyDateFormat()
 	} else {
@@ -51,89 +179,163 @@ func DeriveArrowSchema(obj any, format map[string]DateFormat) (*arrow.Schema, er
 		return nil, fmt.Errorf("support struct only")
 	}
 
-	n := base.NumField()
-
-	fields := make([]arrow.Field, 0, n)
-	for i := 0; i < n; i++ {
-		if field, ok := toArrowField(base.Field(i), format); ok {
-			fields = append(fields, field)
-		}
+	d := &schemaDeriver{format: format, opt: newSchemaOption(optFuncs)}
+	fields, err := d.toArrowFields(base, "")
+	if err != nil {
+		return nil, err
 	}
-
 	return arrow.NewSchema(fields, nil), nil
 }
 
-func toArrowField(sf reflect.StructField, format map[string]DateFormat) (arrow.Field, bool) {
-	tag := sf.Tag.Get("json")
-	if tag == "-" {
-		return arrow.Field{}, false
+// schemaDeriver derives the columns of a struct type. It keeps the struct
+// types being derived to detect recursive types.
+type schemaDeriver struct {
+	format map[string]DateFormat
+	opt    *SchemaOption
+	stack  []reflect.Type
+}
+
+// toArrowFields returns the columns of the struct type t, promoting the fields
+// of embedded structs as encoding/json and UnmarshalRecord do. column is the
+// path of the struct column, empty for the schema.
+func (d *schemaDeriver) toArrowFields(t reflect.Type, column string) ([]arrow.Field, error) {
+	d.stack = append(d.stack, t)
+	defer func() { d.stack = d.stack[:len(d.stack)-1] }()
+
+	goFields := encode.TypeFields(t)
+	fields := make([]arrow.Field, 0, len(goFields))
+	for _, f := range goFields {
+		field, err := d.toArrowField(f, column)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
 	}
+	return fields, nil
+}
 
-	name, _ := parseTag(tag)
-	if !isValidTag(name) {
-		name = ""
+func (d *schemaDeriver) toArrowField(f encode.Field, column string) (arrow.Field, error) {
+	opts, err := parseArrowTag(f.Field.Tag.Get("arrow"))
+	if err != nil {
+		return arrow.Field{}, fmt.Errorf("field %v: %w", f.Field.Name, err)
 	}
 
-	arrowType, metadata := toArrowDataType(sf.Type, name, format)
+	var (
+		arrowType arrow.DataType
+		metadata  arrow.Metadata
+	)
+	if f.Quoted {
+		// the JSON text of the value, as encoding/json quotes it
+		arrowType = &arrow.StringType{}
+	} else {
+		arrowType, metadata, err = d.toArrowDataType(f.Field.Type, f.Name, joinColumn(column, f.Name), opts)
+		if err != nil {
+			return arrow.Field{}, err
+		}
+	}
 
 	return arrow.Field{
-		Name:     name,
+		Name:     f.Name,
 		Type:     arrowType,
 		Nullable: true,
 		Metadata: metadata,
-	}, true
+	}, nil
 }
 
-func toArrowStructType(t reflect.Type, format map[string]DateFormat) arrow.DataType {
-	n := t.NumField()
-	fields := make([]arrow.Field, 0, n)
-	for i := 0; i < n; i++ {
-		if f, ok := toArrowField(t.Field(i), format); ok {
-			fields = append(fields, f)
+// toArrowStructType returns the struct column type of t. A t already being
+// derived is a recursive type: it fails unless SchemaMaxDepth allows
+// unrolling it, the values nested deeper being stored as JSON text.
+func (d *schemaDeriver) toArrowStructType(t reflect.Type, column string) (arrow.DataType, arrow.Metadata, error) {
+	for _, s := range d.stack {
+		if s != t {
+			continue
+		}
+		if d.opt.MaxDepth == 0 {
+			return nil, arrow.Metadata{}, fmt.Errorf("recursive type %v at column %s", t, column)
+		}
+		if len(d.stack) >= d.opt.MaxDepth {
+			return &arrow.StringType{}, arrow.NewMetadata([]string{decode.EncodingMetadataKey}, []string{decode.JSONEncoding}), nil
 		}
+		break
 	}
 
-	return arrow.StructOf(fields...)
+	fields, err := d.toArrowFields(t, column)
+	if err != nil {
+		return nil, arrow.Metadata{}, err
+	}
+	return arrow.StructOf(fields...), arrow.Metadata{}, nil
 }
 
-func toArrowArrayType(t reflect.Type, name string, format map[string]DateFormat) (arrow.DataType, arrow.Metadata) {
-	arrowType, metadata := toArrowDataType(t.Elem(), name, format)
-	return arrow.ListOfField(arrow.Field{Type: arrowType, Nullable: true}), metadata
+// toArrowUnionType returns the union of the fields of the oneof struct t, the
+// type code of each field being its index. The fields are pointers, the
+// non-nil one holding the value.
+func (d *schemaDeriver) toArrowUnionType(t reflect.Type, column string, opts arrowTagOptions) (arrow.DataType, error) {
+	for _, f := range encode.TypeFields(t) {
+		if f.Field.Type.Kind() != reflect.Pointer {
+			return nil, fmt.Errorf("column %s: field %s of oneof struct %v is not a pointer", column, f.Field.Name, t)
+		}
+	}
+	fields, err := d.toArrowFields(t, column)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 || len(fields) > int(arrow.MaxUnionTypeCode)+1 {
+		return nil, fmt.Errorf("column %s: oneof struct %v has %d fields", column, t, len(fields))
+	}
+	codes := make([]arrow.UnionTypeCode, len(fields))
+	for i := range fields {
+		codes[i] = arrow.UnionTypeCode(i)
+		fields[i].Nullable = true
+	}
+	return arrow.UnionOf(opts.mode, fields, codes), nil
+}
+
+func (d *schemaDeriver) toArrowArrayType(t reflect.Type, name, column string, opts arrowTagOptions) (arrow.DataType, arrow.Metadata, error) {
+	arrowType, metadata, err := d.toArrowDataType(t.Elem(), name, column+"[]", opts)
+	if err != nil {
+		return nil, metadata, err
+	}
+	return arrow.ListOfField(arrow.Field{Type: arrowType, Nullable: true}), metadata, nil
 }
 
-func toArrowDataType(base reflect.Type, name string, format map[string]DateFormat) (arrow.DataType, arrow.Metadata) {
+func (d *schemaDeriver) toArrowDataType(base reflect.Type, name, column string, opts arrowTagOptions) (arrow.DataType, arrow.Metadata, error) {
 	if base.Kind() == reflect.Pointer {
 		base = base.Elem()
 	}
 
 	var arrowType arrow.DataType
 	metadata := arrow.Metadata{}
-	if base.Implements(marshalerType) {
-		if base == reflect.TypeOf(time.Time{}) {
-			layout := time.RFC3339Nano
-			if tf, ok := format[name]; ok {
-				if tf.Is32Bits {
-					arrowType = &arrow.Date32Type{}
-				} else {
-					arrowType = &arrow.TimestampType{
-						Unit:     tf.TimeUnit,
-						TimeZone: tf.TimeZone,
-					}
-				}
+	if !tagAppliesTo(opts, base) {
+		return nil, metadata, fmt.Errorf("column %s: arrow tag %s does not apply to type %v", column, opts.kind, base)
+	}
 
-				if len(tf.Layout) > 0 {
-					layout = tf.Layout
-				}
+	if ext := decode.LookupExtension(base); ext != nil {
+		arrowType = ext.Type
+	} else if reflect.PointerTo(base).Implements(arrowTyperType) {
+		arrowType = reflect.New(base).Interface().(ArrowTyper).ArrowType()
+	} else if dt := binaryArrowType(base, opts); dt != nil {
+		arrowType = dt
+	} else if isDecimalType(base) {
+		arrowType = decimalArrowType(opts)
+	} else if base == durationType {
+		arrowType = temporalArrowType(opts, &arrow.DurationType{Unit: arrow.Nanosecond})
+	} else if base == intervalType {
+		arrowType = arrow.FixedWidthTypes.MonthDayNanoInterval
+	} else if base.Implements(marshalerType) {
+		if base == timeType {
+			if opts.kind != "" {
+				arrowType, metadata = temporalArrowType(opts, nil), layoutMetadata(time.RFC3339Nano)
+			} else if tf, ok := d.format[name]; ok {
+				arrowType, metadata = dateFormatArrowType(tf)
 			} else {
-				// default is
-				arrowType = &arrow.TimestampType{Unit: arrow.Millisecond}
+				arrowType, metadata = dateFormatArrowType(defaultDateFormat)
 			}
-			metadata = arrow.NewMetadata([]string{fbs.EnumNamesMetadataKey[fbs.MetadataKeyLAYOUT]}, []string{layout})
 		} else {
 			// it will convert to string
 			arrowType = &arrow.StringType{}
 		}
 	} else {
+		var err error
 		// NOTE: doesn't support uint, uint will be equivalent int type
 		switch base.Kind() {
 		case reflect.Bool:
@@ -165,19 +367,82 @@ func toArrowDataType(base reflect.Type, name string, format map[string]DateForma
 		case reflect.Float64:
 			arrowType = &arrow.Float64Type{}
 		case reflect.Array:
-			arrowType, metadata = toArrowArrayType(base, name, format)
-		case reflect.Map:
-			arrowType = toArrowStructType(base, format)
+			arrowType, metadata, err = d.toArrowArrayType(base, name, column, opts)
 		case reflect.Slice:
-			arrowType, metadata = toArrowArrayType(base, name, format)
+			arrowType, metadata, err = d.toArrowArrayType(base, name, column, opts)
 		case reflect.String:
 			arrowType = &arrow.StringType{}
 		case reflect.Struct:
-			arrowType = toArrowStructType(base, format)
+			if opts.kind == "oneof" {
+				arrowType, err = d.toArrowUnionType(base, column, opts)
+			} else {
+				arrowType, metadata, err = d.toArrowStructType(base, column)
+			}
 		default:
-			panic(fmt.Sprintf("unsupported field type %v: %v ", name, base))
+			// maps, interfaces, channels, functions and complex numbers
+			return nil, metadata, fmt.Errorf("column %s: unsupported type %v", column, base)
 		}
+		if err != nil {
+			return nil, metadata, err
+		}
+	}
+
+	return arrowType, metadata, nil
+}
+
+// layoutMetadata returns the field metadata recording layout.
+func layoutMetadata(layout string) arrow.Metadata {
+	return arrow.NewMetadata([]string{fbs.EnumNamesMetadataKey[fbs.MetadataKeyLAYOUT]}, []string{layout})
+}
+
+func joinColumn(parent, name string) string {
+	if parent == "" {
+		return name
 	}
+	return parent + "." + name
+}
 
-	return arrowType, metadata
+// tagAppliesTo reports whether the arrow tag options can describe values of t.
+func tagAppliesTo(opts arrowTagOptions, t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		// the tag applies to the elements
+		return true
+	}
+	switch opts.kind {
+	case "":
+		return true
+	case "decimal":
+		return isDecimalType(t)
+	case "duration":
+		return t == durationType
+	case "time32", "time64":
+		return t == durationType || t == timeType
+	case "timestamp", "date32", "date64":
+		return t == timeType
+	case "string":
+		return binaryArrowType(t, opts) != nil
+	case "oneof":
+		return t.Kind() == reflect.Struct
+	}
+	return false
+}
+
+// temporalArrowType returns the time or duration column type named by opts,
+// or def when the field has no arrow tag.
+func temporalArrowType(opts arrowTagOptions, def arrow.DataType) arrow.DataType {
+	switch opts.kind {
+	case "duration":
+		return &arrow.DurationType{Unit: opts.unit}
+	case "timestamp":
+		return &arrow.TimestampType{Unit: opts.unit}
+	case "time32":
+		return &arrow.Time32Type{Unit: opts.unit}
+	case "time64":
+		return &arrow.Time64Type{Unit: opts.unit}
+	case "date32":
+		return arrow.FixedWidthTypes.Date32
+	case "date64":
+		return arrow.FixedWidthTypes.Date64
+	}
+	return def
 }
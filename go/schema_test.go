@@ -1,14 +1,24 @@
 package client
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/netip"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/apache/arrow/go/v10/arrow"
 	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/flight"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
 	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/chronowave/fbs/go"
 )
 
 func TestDeriveArrowSchema(t *testing.T) {
@@ -27,6 +37,356 @@ func TestDeriveArrowSchema(t *testing.T) {
 	}
 }
 
+func TestDeriveArrowSchemaNames(t *testing.T) {
+	type inner struct {
+		X int
+	}
+	type Inner struct {
+		Y int
+	}
+	type myInt int
+	type MyInt int
+	type left struct {
+		Dup int
+		Won int `json:"Won"`
+	}
+	type right struct {
+		Dup int
+		Won int
+	}
+	type viaA struct {
+		inner
+		A int
+	}
+	type viaB struct {
+		inner
+		B int
+	}
+	type Left struct {
+		Both int `json:"both"`
+		Won  int `json:"Won"`
+	}
+	type Right struct {
+		Both int `json:"both"`
+		Won  int
+	}
+	// built with reflect.StructOf, as vet rejects the json tags repeated
+	// by the structs embedded in a struct literal
+	tagged := reflect.New(reflect.StructOf([]reflect.StructField{
+		{Name: "Left", Type: reflect.TypeOf(Left{}), Anonymous: true},
+		{Name: "Right", Type: reflect.TypeOf(Right{}), Anonymous: true},
+	})).Elem().Interface()
+
+	for _, tc := range []struct {
+		name string
+		v    any
+		want []string
+	}{
+		{"untagged", struct{ A int }{}, []string{"A"}},
+		{"tagged", struct {
+			A int `json:"a"`
+		}{}, []string{"a"}},
+		{"skipped", struct {
+			A int `json:"-"`
+			B int
+		}{}, []string{"B"}},
+		{"dash name", struct {
+			A int `json:"-,"`
+		}{}, []string{"-"}},
+		{"options only", struct {
+			A int `json:",omitempty"`
+		}{}, []string{"A"}},
+		{"invalid name", struct {
+			A int `json:"a'b"`
+		}{}, []string{"A"}},
+		{"punctuation", struct {
+			A int `json:"a;b"`
+			B int `json:"x-y.z"`
+		}{}, []string{"a;b", "x-y.z"}},
+		{"unicode", struct {
+			A int `json:"名前"`
+		}{}, []string{"名前"}},
+		{"unexported", struct {
+			a int
+			B int
+		}{}, []string{"B"}},
+		{"embedded unexported struct", struct{ inner }{}, []string{"X"}},
+		{"embedded pointer", struct{ *Inner }{}, []string{"Y"}},
+		{"embedded unexported non-struct", struct {
+			myInt
+			B int
+		}{}, []string{"B"}},
+		{"embedded exported non-struct", struct{ MyInt }{}, []string{"MyInt"}},
+		{"embedded tagged", struct {
+			Inner `json:"inner"`
+		}{}, []string{"inner"}},
+		{"embedded unmarshaler", struct{ time.Time }{}, []string{"Time"}},
+		{"embedded conflicts", struct {
+			left
+			right
+		}{}, []string{"Won"}},
+		{"embedded tagged conflicts", tagged, []string{"Won"}},
+		{"embedded twice at a depth", struct {
+			viaA
+			viaB
+		}{}, []string{"A", "B"}},
+		{"outer hides embedded", struct {
+			inner
+			X string `json:"X"`
+		}{}, []string{"X"}},
+		{"declaration order", struct {
+			B int
+			inner
+			A int
+		}{}, []string{"B", "X", "A"}},
+	} {
+		schema, err := DeriveArrowSchema(tc.v, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", tc.name, err)
+			continue
+		}
+		var got []string
+		for _, f := range schema.Fields() {
+			got = append(got, f.Name)
+		}
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("%s: want=%v, got=%v", tc.name, tc.want, got)
+		}
+	}
+
+	// the columns of a schema derived from a type decode back into it
+	type twice struct {
+		viaA
+		viaB
+	}
+	rows := []twice{{viaA: viaA{inner: inner{X: 1}, A: 2}, viaB: viaB{B: 3}}}
+	schema, err := DeriveArrowSchema(twice{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	record, err := MarshalRecord(rows, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	var got []twice
+	if err := UnmarshalRecordWithOptions(record, &got, DisallowUnknownColumns()); err != nil || len(got) != 1 || got[0].A != 2 || got[0].B != 3 || got[0].viaA.X != 0 {
+		t.Errorf("unexpected rows: %+v, %v", got, err)
+	}
+}
+
+func TestDeriveArrowSchemaTagOptions(t *testing.T) {
+	type options struct {
+		N int      `json:"n,string"`
+		P *float64 `json:"p,string"`
+		B bool     `json:"b,string"`
+		S string   `json:"s,omitempty"`
+		L []int    `json:"l,string"`
+	}
+	schema, err := DeriveArrowSchema(options{}, nil)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	want := []arrow.DataType{
+		&arrow.StringType{},
+		&arrow.StringType{},
+		&arrow.StringType{},
+		&arrow.StringType{},
+		arrow.ListOfField(arrow.Field{Type: &arrow.Int32Type{}, Nullable: true}),
+	}
+	for i, f := range schema.Fields() {
+		if !arrow.TypeEqual(f.Type, want[i]) {
+			t.Errorf("field %s: want=%v, got=%v", f.Name, want[i], f.Type)
+		}
+	}
+
+	p := 1.5
+	in := []options{{N: 42, P: &p, B: true, L: []int{1}}}
+	record, err := MarshalRecord(in, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	if got := record.Column(0).(*array.String).Value(0); got != "42" {
+		t.Errorf("want n=42, got %s", got)
+	}
+	if !record.Column(3).IsNull(0) {
+		t.Errorf("want null for empty omitempty field")
+	}
+
+	var out []options
+	if err := UnmarshalRecord(record, &out); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("want=%+v, got=%+v", in[0], out[0])
+	}
+}
+
+func TestUnmarshalRecordQuotedStrings(t *testing.T) {
+	type quoted struct {
+		S string `json:"s,string"`
+	}
+	in := []quoted{{S: "first"}, {S: "second"}, {S: "third"}}
+	schema, _ := DeriveArrowSchema(quoted{}, nil)
+	record, err := MarshalRecord(in, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	// each string outlives the decoding of the next one
+	var out []quoted
+	if err := UnmarshalRecord(record, &out); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("want=%+v, got=%+v", in, out)
+	}
+}
+
+type spanBase struct {
+	ID      string `json:"id"`
+	Service string `json:"service"`
+}
+
+type SpanMeta struct {
+	Service string `json:"service"`
+	Host    string `json:"host"`
+	Zone    string
+}
+
+type spanPlacement struct {
+	Zone string
+	Rack string `json:"rack"`
+}
+
+type embeddedSpan struct {
+	spanBase
+	*SpanMeta
+	spanPlacement
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestDeriveArrowSchemaEmbedded(t *testing.T) {
+	schema, err := DeriveArrowSchema(embeddedSpan{}, nil)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	// id is hidden by the outer field, service and Zone conflict at the same depth
+	want := []arrow.Field{
+		{Name: "host", Type: &arrow.StringType{}, Nullable: true},
+		{Name: "rack", Type: &arrow.StringType{}, Nullable: true},
+		{Name: "id", Type: &arrow.Int64Type{}, Nullable: true},
+		{Name: "name", Type: &arrow.StringType{}, Nullable: true},
+	}
+	if !reflect.DeepEqual(want, schema.Fields()) {
+		t.Errorf("want=%v, got=%v", want, schema.Fields())
+	}
+
+	in := []embeddedSpan{{SpanMeta: &SpanMeta{Host: "db-1"}, spanPlacement: spanPlacement{Rack: "r7"}, ID: 42, Name: "query"}}
+	record, err := MarshalRecord(in, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	var out []embeddedSpan
+	if err := UnmarshalRecord(record, &out); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("want=%+v, got=%+v", in[0], out[0])
+	}
+}
+
+type treeSpan struct {
+	Name     string     `json:"name"`
+	Children []treeSpan `json:"children"`
+	Parent   *treeSpan  `json:"parent"`
+}
+
+func TestDeriveArrowSchemaRecursive(t *testing.T) {
+	if _, err := DeriveArrowSchema(treeSpan{}, nil); err == nil || !strings.Contains(err.Error(), "children") {
+		t.Errorf("want recursive type error at children, got=%v", err)
+	}
+
+	schema, err := DeriveArrowSchemaWithOptions(treeSpan{}, nil, SchemaMaxDepth(2))
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	children := schema.Field(1).Type.(*arrow.ListType).ElemField()
+	truncated := children.Type.(*arrow.StructType).Field(1)
+	if list, ok := truncated.Type.(*arrow.ListType); !ok || list.Elem().ID() != arrow.STRING {
+		t.Errorf("want grandchildren truncated to strings, got=%v", truncated)
+	}
+	if i := truncated.Metadata.FindKey("chronowave.client.encoding"); i < 0 || truncated.Metadata.Values()[i] != "json" {
+		t.Errorf("want json encoding, got=%v", truncated.Metadata)
+	}
+	if truncated.Metadata.FindKey(fbs.EnumNamesMetadataKey[fbs.MetadataKeyLAYOUT]) >= 0 {
+		t.Errorf("want no LAYOUT metadata, got=%v", truncated.Metadata)
+	}
+
+	in := []treeSpan{{
+		Name: "root",
+		Children: []treeSpan{{
+			Name:     "child",
+			Children: []treeSpan{{Name: "leaf", Children: []treeSpan{}}},
+		}},
+	}, {
+		// null lists of unrolled levels decode as empty ones
+		Name:     "orphan",
+		Children: []treeSpan{},
+		Parent:   &treeSpan{Name: "root", Children: []treeSpan{}, Parent: &treeSpan{Name: "origin"}},
+	}}
+	record, err := MarshalRecord(in, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	var out []treeSpan
+	if err := UnmarshalRecord(record, &out); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("want=%+v, got=%+v", in, out)
+	}
+}
+
+func TestDeriveArrowSchemaUnsupportedTypes(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		v    any
+	}{
+		{"chan", struct{ C chan int }{}},
+		{"func", struct{ F func() }{}},
+		{"interface", struct{ I any }{}},
+		{"complex", struct{ C complex128 }{}},
+		{"map", struct{ M map[string]int }{}},
+		{"nested map", struct{ S []struct{ M map[string]int } }{}},
+	} {
+		_, err := DeriveArrowSchema(tc.v, nil)
+		if err == nil || !strings.Contains(err.Error(), "unsupported type") {
+			t.Errorf("%s: want unsupported type error, got=%v", tc.name, err)
+		}
+	}
+}
+
 func TestUnmarshallRecord(t *testing.T) {
 	dt := arrow.ListOfField(arrow.Field{
 		Type: &arrow.TimestampType{
@@ -73,3 +433,1461 @@ func TestUnmarshallRecord(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalRecordLargeTypes(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: "name", Type: arrow.BinaryTypes.LargeString, Nullable: true},
+		{Name: "tags", Type: arrow.LargeListOf(arrow.BinaryTypes.LargeString), Nullable: true},
+		{Name: "pair", Type: arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Int32), Nullable: true},
+		{Name: "list", Type: arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Int32), Nullable: true},
+		{Name: "body", Type: arrow.BinaryTypes.LargeBinary, Nullable: true},
+	}
+	schema := arrow.NewSchema(fields, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	builder.Field(0).(*array.LargeStringBuilder).AppendValues([]string{"a", "b"}, nil)
+	tags := builder.Field(1).(*array.LargeListBuilder)
+	tags.Append(true)
+	tags.ValueBuilder().(*array.LargeStringBuilder).AppendValues([]string{"x", "y"}, nil)
+	tags.AppendNull()
+	for _, fb := range []array.Builder{builder.Field(2), builder.Field(3)} {
+		list := fb.(*array.FixedSizeListBuilder)
+		list.AppendValues([]bool{true, true})
+		list.ValueBuilder().(*array.Int32Builder).AppendValues([]int32{1, 2, 3, 4}, nil)
+	}
+	builder.Field(4).(*array.BinaryBuilder).AppendValues([][]byte{[]byte("doc-a"), []byte("doc-b")}, nil)
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	type row struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+		Pair [2]int32 `json:"pair"`
+		List []int32  `json:"list"`
+		Body []byte   `json:"body"`
+	}
+	var got []row
+	if err := UnmarshalRecord(record, &got); err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+
+	want := []row{
+		{Name: "a", Tags: []string{"x", "y"}, Pair: [2]int32{1, 2}, List: []int32{1, 2}, Body: []byte("doc-a")},
+		{Name: "b", Tags: []string{}, Pair: [2]int32{3, 4}, List: []int32{3, 4}, Body: []byte("doc-b")},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want=%v, got=%v", want, got)
+	}
+}
+
+func TestUnmarshalRecordTypeMismatch(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: "span_id", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "pair", Type: arrow.ListOf(arrow.PrimitiveTypes.Int32), Nullable: true},
+	}
+	schema := arrow.NewSchema(fields, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	builder.Field(0).(*array.StringBuilder).AppendValues([]string{"a", "b"}, nil)
+	list := builder.Field(1).(*array.ListBuilder)
+	list.Append(true)
+	list.ValueBuilder().(*array.Int32Builder).AppendValues([]int32{1, 2}, nil)
+	list.Append(true)
+	list.ValueBuilder().(*array.Int32Builder).AppendValues([]int32{3, 4, 5}, nil)
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var mismatch []struct {
+		Span int64 `json:"span_id"`
+	}
+	err := UnmarshalRecord(record, &mismatch)
+	typeErr, ok := err.(*UnmarshalTypeError)
+	if !ok {
+		t.Errorf("want *UnmarshalTypeError, got=%v", err)
+		return
+	}
+	if typeErr.Column != "span_id" || typeErr.ArrowType.ID() != arrow.STRING || typeErr.Type != reflect.TypeOf(int64(0)) || typeErr.Row != -1 {
+		t.Errorf("unexpected error: %v", typeErr)
+	}
+
+	var pairs []struct {
+		Pair [2]int32 `json:"pair"`
+	}
+	err = UnmarshalRecord(record, &pairs)
+	typeErr, ok = err.(*UnmarshalTypeError)
+	if !ok {
+		t.Errorf("want *UnmarshalTypeError, got=%v", err)
+		return
+	}
+	if typeErr.Column != "pair" || typeErr.Row != 1 {
+		t.Errorf("unexpected error: %v", typeErr)
+	}
+
+	var notSlice struct{}
+	if err := UnmarshalRecord(record, &notSlice); err == nil {
+		t.Errorf("want error for non-slice target")
+	}
+}
+
+func TestUnmarshalRecordPlan(t *testing.T) {
+	fields := []arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "score", Type: arrow.PrimitiveTypes.Uint16, Nullable: true},
+	}
+	schema := arrow.NewSchema(fields, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	builder.Field(0).(*array.Int32Builder).AppendValues([]int32{1, 2, 3}, []bool{true, false, true})
+	builder.Field(1).(*array.StringBuilder).AppendValues([]string{"a", "", "c"}, []bool{true, false, true})
+	builder.Field(2).(*array.Uint16Builder).AppendValues([]uint16{10, 20, 30}, nil)
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	type row struct {
+		ID    int64   `json:"id"`
+		Name  *string `json:"name"`
+		Score float32 `json:"score"`
+	}
+
+	// decode twice to run the cached plan
+	for i := 0; i < 2; i++ {
+		var rows []row
+		if err := UnmarshalRecord(record, &rows); err != nil {
+			t.Errorf("UnmarshalRecord: %v", err)
+			return
+		}
+		if len(rows) != 3 || rows[0].ID != 1 || rows[1].ID != 0 || rows[2].Score != 30 {
+			t.Errorf("unexpected rows: %+v", rows)
+		}
+		if rows[0].Name == nil || *rows[0].Name != "a" || rows[1].Name != nil {
+			t.Errorf("unexpected names: %+v", rows)
+		}
+
+		var ptrs []*row
+		if err := UnmarshalRecord(record, &ptrs); err != nil {
+			t.Errorf("UnmarshalRecord: %v", err)
+			return
+		}
+		if len(ptrs) != 3 || ptrs[2].ID != 3 || *ptrs[2].Name != "c" || ptrs[1].Score != 20 {
+			t.Errorf("unexpected rows: %+v", ptrs)
+		}
+	}
+}
+
+func TestUnmarshalRecordPlanCacheLimit(t *testing.T) {
+	type row struct {
+		ID int32 `json:"id"`
+	}
+	// more schemas than plans cached, then the first ones again
+	for n := 0; n < 600; n++ {
+		i := n % 400
+		schema := arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+			{Name: fmt.Sprintf("c%d", i), Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+		}, nil)
+		builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+		builder.Field(0).(*array.Int32Builder).Append(int32(i))
+		builder.Field(1).(*array.Int32Builder).Append(0)
+		record := builder.NewRecord()
+		builder.Release()
+
+		var rows []row
+		err := UnmarshalRecord(record, &rows)
+		record.Release()
+		if err != nil || len(rows) != 1 || rows[0].ID != int32(i) {
+			t.Errorf("schema %d: unexpected rows: %+v, %v", i, rows, err)
+			return
+		}
+	}
+}
+
+func TestUnmarshalRecordStrict(t *testing.T) {
+	meta := arrow.StructOf(
+		arrow.Field{Name: "host", Type: arrow.BinaryTypes.String, Nullable: true},
+		arrow.Field{Name: "zone", Type: arrow.BinaryTypes.String, Nullable: true},
+	)
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+		{Name: "meta", Type: meta, Nullable: true},
+	}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	builder.Field(0).(*array.Int32Builder).AppendValues([]int32{1, 0}, []bool{true, false})
+	mb := builder.Field(1).(*array.StructBuilder)
+	mb.AppendValues([]bool{true, true})
+	mb.FieldBuilder(0).(*array.StringBuilder).AppendValues([]string{"db-1", "db-2"}, nil)
+	mb.FieldBuilder(1).(*array.StringBuilder).AppendValues([]string{"eu", "us"}, nil)
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	type hostMeta struct {
+		Host string `json:"host"`
+	}
+	type span struct {
+		ID   int32    `json:"id"`
+		Meta hostMeta `json:"meta"`
+	}
+	var rows []span
+	err := UnmarshalRecordWithOptions(record, &rows, DisallowUnknownColumns())
+	if e, ok := err.(*UnknownColumnError); !ok || e.Column != "meta.zone" || e.Struct != "hostMeta" {
+		t.Errorf("want *UnknownColumnError for meta.zone, got=%v", err)
+	}
+	if err := UnmarshalRecordWithOptions(record, &rows, RequireAllFields()); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	type tracedSpan struct {
+		ID    int32  `json:"id"`
+		Trace string `json:"trace"`
+	}
+	var traced []tracedSpan
+	err = UnmarshalRecordWithOptions(record, &traced, RequireAllFields())
+	if e, ok := err.(*MissingColumnError); !ok || e.Column != "trace" || e.Field != "trace" {
+		t.Errorf("want *MissingColumnError for trace, got=%v", err)
+	}
+	var columns struct {
+		Trace []string `json:"trace"`
+	}
+	if err := UnmarshalColumns(record, &columns, RequireAllFields()); err == nil {
+		t.Errorf("want *MissingColumnError for trace")
+	}
+
+	err = UnmarshalRecordWithOptions(record, &rows, DecodeNullPolicy(ErrorNullPolicy))
+	if e, ok := err.(*UnmarshalTypeError); !ok || e.Value != "null" || e.Column != "id" || e.Row != 1 {
+		t.Errorf("want *UnmarshalTypeError for the null id at row 1, got=%v", err)
+	}
+	for policy, id := range map[NullPolicy]int32{ZeroNullPolicy: 0, KeepNullPolicy: 9} {
+		rows = []span{{ID: 7, Meta: hostMeta{Host: "old-1"}}, {ID: 9, Meta: hostMeta{Host: "old-2"}}}
+		if err := UnmarshalRecordWithOptions(record, &rows, DecodeNullPolicy(policy), DecodeSliceMode(ReuseSliceMode)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+			continue
+		}
+		want := []span{{ID: 1, Meta: hostMeta{Host: "db-1"}}, {ID: id, Meta: hostMeta{Host: "db-2"}}}
+		if !reflect.DeepEqual(want, rows) {
+			t.Errorf("policy %v: want=%+v, got=%+v", policy, want, rows)
+		}
+	}
+}
+
+func TestUnmarshalRecordColumnNames(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "SERVICE", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "http_status", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+		{Name: "Host", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "host", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	builder.Field(0).(*array.StringBuilder).Append("frontend")
+	builder.Field(1).(*array.Int32Builder).Append(200)
+	builder.Field(2).(*array.StringBuilder).Append("old")
+	builder.Field(3).(*array.StringBuilder).Append("db-1")
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	type span struct {
+		Service string `json:"service"`
+		Status  int    `json:"status,alias=http_status,alias=code"`
+		Host    string `json:"host"`
+	}
+	var rows []span
+	if err := UnmarshalRecord(record, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	// the exact match of host wins over Host
+	want := []span{{Service: "frontend", Status: 200, Host: "db-1"}}
+	if !reflect.DeepEqual(want, rows) {
+		t.Errorf("want=%+v, got=%+v", want, rows)
+	}
+	var columns struct {
+		Status []int `json:"status,alias=http_status"`
+	}
+	if err := UnmarshalColumns(record, &columns); err != nil || len(columns.Status) != 1 || columns.Status[0] != 200 {
+		t.Errorf("unexpected columns: %+v, %v", columns, err)
+	}
+
+	encoded, err := MarshalRecord(rows, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer encoded.Release()
+	if encoded.Column(0).(*array.String).Value(0) != "frontend" || encoded.Column(1).(*array.Int32).Value(0) != 200 {
+		t.Errorf("unexpected record: %v", encoded)
+	}
+}
+
+func TestUnmarshalColumns(t *testing.T) {
+	record := newBenchRecord(4)
+	defer record.Release()
+
+	var cols struct {
+		Duration []int64    `json:"duration"`
+		Service  []string   `json:"service"`
+		Status   []int      `json:"status"`
+		Tags     [][]string `json:"tags"`
+	}
+	if err := UnmarshalColumns(record, &cols); err != nil {
+		t.Errorf("UnmarshalColumns: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(cols.Duration, []int64{0, 1, 2, 3}) {
+		t.Errorf("unexpected durations: %v", cols.Duration)
+	}
+	if !reflect.DeepEqual(cols.Service, []string{"frontend", "checkout", "payment", "shipping"}) {
+		t.Errorf("unexpected services: %v", cols.Service)
+	}
+	if !reflect.DeepEqual(cols.Status, []int{200, 201, 202, 200}) {
+		t.Errorf("unexpected status: %v", cols.Status)
+	}
+	if len(cols.Tags) != 4 || cols.Tags[3][0] != "3" {
+		t.Errorf("unexpected tags: %v", cols.Tags)
+	}
+
+	var mismatch struct {
+		Service []int64 `json:"service"`
+	}
+	err := UnmarshalColumns(record, &mismatch)
+	if typeErr, ok := err.(*UnmarshalTypeError); !ok || typeErr.Column != "service" {
+		t.Errorf("want *UnmarshalTypeError for column service, got=%v", err)
+	}
+
+	var rows []benchSpan
+	if err := UnmarshalColumns(record, &rows); err == nil {
+		t.Errorf("want error for non-struct target")
+	}
+}
+
+func TestUnmarshalRecordSliceMode(t *testing.T) {
+	record := newBenchRecord(4)
+	defer record.Release()
+
+	rows := make([]benchSpan, 1, 8)
+	rows[0].Service = "kept"
+	if err := UnmarshalRecordWithOptions(record, &rows, DecodeSliceMode(AppendSliceMode)); err != nil {
+		t.Errorf("UnmarshalRecordWithOptions: %v", err)
+		return
+	}
+	first := &rows[0]
+	if err := UnmarshalRecordWithOptions(record, &rows, DecodeSliceMode(AppendSliceMode)); err != nil {
+		t.Errorf("UnmarshalRecordWithOptions: %v", err)
+		return
+	}
+	if len(rows) != 9 || rows[0].Service != "kept" || rows[4].Duration != 3 || rows[8].Duration != 3 {
+		t.Errorf("want rows appended twice, got=%+v", rows)
+	}
+	if first == &rows[0] {
+		t.Errorf("want a grown slice")
+	}
+
+	rows = rows[:0]
+	backing := &rows[:1][0]
+	backing.Tags = []string{"stale", "tags"}
+	if err := UnmarshalRecordWithOptions(record, &rows, DecodeSliceMode(ReuseSliceMode)); err != nil {
+		t.Errorf("UnmarshalRecordWithOptions: %v", err)
+		return
+	}
+	if len(rows) != 4 || &rows[0] != backing || !reflect.DeepEqual(rows[0].Tags, []string{"0"}) || rows[3].Service != "shipping" {
+		t.Errorf("want rows decoded in place, got=%+v", rows)
+	}
+	// strings aliasing the record leave the tags lists to allocate
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = UnmarshalRecordWithOptions(record, &rows, DecodeSliceMode(ReuseSliceMode), DecodeZeroCopy())
+	})
+	if allocs > 8 {
+		t.Errorf("want few allocations reusing rows, got=%v", allocs)
+	}
+
+	ptrs := []*benchSpan{{Service: "stale", Error: true}}
+	ptrs = append(ptrs, make([]*benchSpan, 3)...)
+	span := ptrs[0]
+	if err := UnmarshalRecordWithOptions(record, &ptrs, DecodeSliceMode(ReuseSliceMode)); err != nil {
+		t.Errorf("UnmarshalRecordWithOptions: %v", err)
+		return
+	}
+	if ptrs[0] != span || span.Service != "frontend" || !span.Error || ptrs[3] == nil || ptrs[3].Duration != 3 {
+		t.Errorf("want pointees reused, got=%+v", span)
+	}
+	ptrs[1].Error = true
+	if err := UnmarshalRecordWithOptions(record, &ptrs, DecodeSliceMode(ReuseSliceMode)); err != nil || ptrs[1].Error {
+		t.Errorf("want reset pointees, got=%+v, %v", ptrs[1], err)
+	}
+
+	var cols struct {
+		Duration []int64 `json:"duration"`
+	}
+	cols.Duration = []int64{-1}
+	if err := UnmarshalColumns(record, &cols, DecodeSliceMode(AppendSliceMode)); err != nil || !reflect.DeepEqual(cols.Duration, []int64{-1, 0, 1, 2, 3}) {
+		t.Errorf("unexpected columns: %v, %v", cols.Duration, err)
+	}
+	if err := UnmarshalColumns(record, &cols, DecodeSliceMode(ReuseSliceMode)); err != nil || !reflect.DeepEqual(cols.Duration, []int64{0, 1, 2, 3}) {
+		t.Errorf("unexpected columns: %v, %v", cols.Duration, err)
+	}
+}
+
+func TestUnmarshalRecordParallel(t *testing.T) {
+	record := newBenchRecord(10_000)
+	defer record.Release()
+
+	var want []benchSpan
+	if err := UnmarshalRecord(record, &want); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	var rows []benchSpan
+	if err := UnmarshalRecordWithOptions(record, &rows, DecodeParallel(4, 1000)); err != nil {
+		t.Errorf("UnmarshalRecordWithOptions: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(want, rows) {
+		t.Errorf("want the rows decoded sequentially")
+	}
+	var ptrs []*benchSpan
+	if err := UnmarshalRecordWithOptions(record, &ptrs, DecodeParallel(3, 999)); err != nil || len(ptrs) != len(want) || !reflect.DeepEqual(*ptrs[9999], want[9999]) {
+		t.Errorf("unexpected pointer rows: %v", err)
+	}
+	var cols struct {
+		Duration []int64    `json:"duration"`
+		Tags     [][]string `json:"tags"`
+	}
+	if err := UnmarshalColumns(record, &cols, DecodeParallel(0, 100)); err != nil || cols.Duration[9999] != 9999 || cols.Tags[9999][0] != "9" {
+		t.Errorf("unexpected columns: %v", err)
+	}
+
+	type strictSpan struct {
+		Duration int64  `json:"duration"`
+		Service  string `json:"service"`
+	}
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema([]arrow.Field{
+		{Name: "duration", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	}, nil))
+	defer builder.Release()
+	for i := 0; i < 10_000; i++ {
+		if i == 2_222 || i == 7_777 {
+			builder.Field(0).AppendNull()
+		} else {
+			builder.Field(0).(*array.Int64Builder).Append(int64(i))
+		}
+	}
+	nulls := builder.NewRecord()
+	defer nulls.Release()
+	for i := 0; i < 20; i++ {
+		var strict []strictSpan
+		err := UnmarshalRecordWithOptions(nulls, &strict, DecodeParallel(4, 1000), DecodeNullPolicy(ErrorNullPolicy))
+		if e, ok := err.(*UnmarshalTypeError); !ok || e.Row != 2222 {
+			t.Errorf("want null error of row 2222, got=%v", err)
+			return
+		}
+	}
+
+	// rows decode column by column, so the first column failing is reported
+	// even when another one fails in an earlier chunk
+	type pair struct {
+		A int64 `json:"a"`
+		B int64 `json:"b"`
+	}
+	builder = array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema([]arrow.Field{
+		{Name: "a", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "b", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	}, nil))
+	defer builder.Release()
+	for i := 0; i < 10_000; i++ {
+		for col, null := range []int{9_000, 10} {
+			if i == null {
+				builder.Field(col).AppendNull()
+			} else {
+				builder.Field(col).(*array.Int64Builder).Append(int64(i))
+			}
+		}
+	}
+	twoNulls := builder.NewRecord()
+	defer twoNulls.Release()
+	var sequential, parallel []pair
+	seqErr := UnmarshalRecordWithOptions(twoNulls, &sequential, DecodeNullPolicy(ErrorNullPolicy))
+	parErr := UnmarshalRecordWithOptions(twoNulls, &parallel, DecodeParallel(4, 1000), DecodeNullPolicy(ErrorNullPolicy))
+	if e, ok := seqErr.(*UnmarshalTypeError); !ok || e.Column != "a" || e.Row != 9000 {
+		t.Errorf("want null error of column a row 9000, got=%v", seqErr)
+	}
+	if parErr == nil || seqErr == nil || parErr.Error() != seqErr.Error() {
+		t.Errorf("want=%v, got=%v", seqErr, parErr)
+	}
+}
+
+func TestUnmarshalRecordZeroCopy(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "service", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String), Nullable: true},
+		{Name: "payload", Type: arrow.BinaryTypes.Binary, Nullable: true},
+	}, nil)
+	builder := array.NewRecordBuilder(mem, schema)
+	builder.Field(0).(*array.StringBuilder).AppendValues([]string{"frontend", "checkout"}, nil)
+	tags := builder.Field(1).(*array.ListBuilder)
+	tags.Append(true)
+	tags.ValueBuilder().(*array.StringBuilder).Append("web")
+	tags.AppendNull()
+	builder.Field(2).(*array.BinaryBuilder).AppendValues([][]byte{[]byte("ab"), nil}, []bool{true, false})
+	record := builder.NewRecord()
+	builder.Release()
+
+	type span struct {
+		Service string   `json:"service"`
+		Tags    []string `json:"tags"`
+		Payload []byte   `json:"payload"`
+	}
+	values := record.Column(0).(*array.String).ValueBytes()
+	aliases := func(s string) bool {
+		p := (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+		start := uintptr(unsafe.Pointer(&values[0]))
+		return p >= start && p < start+uintptr(len(values))
+	}
+	payloads := record.Column(2).(*array.Binary).ValueBytes()
+	aliasesPayload := func(b []byte) bool {
+		return len(b) > 0 && &b[0] == &payloads[0]
+	}
+
+	var copied []span
+	if err := UnmarshalRecord(record, &copied); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if copied[1].Service != "checkout" || aliases(copied[0].Service) || aliases(copied[1].Service) || aliasesPayload(copied[0].Payload) {
+		t.Errorf("want strings copied from the record, got=%+v", copied)
+	}
+
+	var rows []span
+	ref, err := UnmarshalRecordZeroCopy(record, &rows)
+	if err != nil {
+		t.Errorf("UnmarshalRecordZeroCopy: %v", err)
+		return
+	}
+	if !aliases(rows[0].Service) || !aliases(rows[1].Service) || rows[0].Tags[0] != "web" {
+		t.Errorf("want strings aliasing the record, got=%+v", rows)
+	}
+	if !aliasesPayload(rows[0].Payload) || cap(rows[0].Payload) != 2 || rows[1].Payload != nil {
+		t.Errorf("want bytes aliasing the record, got=%+v", rows)
+	}
+	if _, err := UnmarshalRecordZeroCopy(record, rows); err == nil {
+		t.Errorf("want error for non-pointer target")
+	}
+	record.Release()
+	if mem.CurrentAlloc() == 0 {
+		t.Errorf("want the record retained by its RecordRef")
+	}
+	if rows[1].Service != "checkout" {
+		t.Errorf("unexpected service: %v", rows[1].Service)
+	}
+	ref.Release()
+	ref.Release()
+}
+
+// testIPv4 is stored as a uint32 column, although it is also a
+// TextUnmarshaler.
+type testIPv4 [4]byte
+
+func (ip testIPv4) ArrowType() arrow.DataType { return arrow.PrimitiveTypes.Uint32 }
+
+func (ip testIPv4) MarshalArrow(b array.Builder) error {
+	b.(*array.Uint32Builder).Append(uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3]))
+	return nil
+}
+
+func (ip *testIPv4) UnmarshalArrow(arr arrow.Array, i int) error {
+	v := arr.(*array.Uint32).Value(i)
+	*ip = testIPv4{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	return nil
+}
+
+func (ip *testIPv4) UnmarshalText(text []byte) error {
+	return fmt.Errorf("unexpected UnmarshalText")
+}
+
+// testGeoPoint is stored as a fixed size list of its coordinates rather than
+// as a struct column.
+type testGeoPoint struct {
+	Lat, Lon float64
+}
+
+func (p testGeoPoint) ArrowType() arrow.DataType {
+	return arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Float64)
+}
+
+func (p testGeoPoint) MarshalArrow(b array.Builder) error {
+	if p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range", p.Lat)
+	}
+	list := b.(*array.FixedSizeListBuilder)
+	list.Append(true)
+	list.ValueBuilder().(*array.Float64Builder).AppendValues([]float64{p.Lat, p.Lon}, nil)
+	return nil
+}
+
+func (p *testGeoPoint) UnmarshalArrow(arr arrow.Array, i int) error {
+	values := arr.(*array.FixedSizeList).ListValues().(*array.Float64)
+	p.Lat, p.Lon = values.Value(2*i), values.Value(2*i+1)
+	return nil
+}
+
+func TestArrowMarshaler(t *testing.T) {
+	type host struct {
+		Name     string        `json:"name"`
+		IP       testIPv4      `json:"ip"`
+		Gateway  *testIPv4     `json:"gateway"`
+		Location testGeoPoint  `json:"location"`
+		Peers    []testIPv4    `json:"peers"`
+		Previous *testGeoPoint `json:"previous"`
+	}
+	schema, err := DeriveArrowSchema(host{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	want := []arrow.DataType{
+		arrow.BinaryTypes.String,
+		arrow.PrimitiveTypes.Uint32,
+		arrow.PrimitiveTypes.Uint32,
+		arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Float64),
+		arrow.ListOfField(arrow.Field{Type: arrow.PrimitiveTypes.Uint32, Nullable: true}),
+		arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Float64),
+	}
+	for i, dt := range want {
+		if !arrow.TypeEqual(schema.Field(i).Type, dt) {
+			t.Errorf("column %s: want=%v, got=%v", schema.Field(i).Name, dt, schema.Field(i).Type)
+		}
+	}
+
+	hosts := []host{
+		{Name: "a", IP: testIPv4{10, 0, 0, 1}, Gateway: &testIPv4{10, 0, 0, 254}, Location: testGeoPoint{48.85, 2.35}, Peers: []testIPv4{{10, 0, 0, 2}}, Previous: &testGeoPoint{3, 4}},
+		{Name: "b", IP: testIPv4{192, 168, 1, 1}, Location: testGeoPoint{-33.87, 151.21}, Peers: []testIPv4{}, Previous: &testGeoPoint{1, 2}},
+	}
+	record, err := MarshalRecord(hosts, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	if v := record.Column(1).(*array.Uint32).Value(0); v != 0x0a000001 {
+		t.Errorf("unexpected ip column value: %x", v)
+	}
+	if !record.Column(2).IsNull(1) {
+		t.Errorf("want a null value for a nil pointer")
+	}
+
+	var got []host
+	if err := UnmarshalRecord(record, &got); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(hosts, got) {
+		t.Errorf("want=%+v, got=%+v", hosts, got)
+	}
+
+	if _, err := MarshalRecord([]host{{Location: testGeoPoint{Lat: 100}}}, schema); err == nil || !strings.Contains(err.Error(), "latitude 100 out of range") {
+		t.Errorf("want MarshalArrow error, got=%v", err)
+	}
+
+	mismatch := arrow.NewSchema([]arrow.Field{{Name: "ip", Type: arrow.BinaryTypes.String, Nullable: true}}, nil)
+	if _, err := MarshalRecord(hosts, mismatch); err == nil {
+		t.Errorf("want error encoding into a column of another type")
+	}
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, mismatch)
+	defer builder.Release()
+	builder.Field(0).(*array.StringBuilder).Append("10.0.0.1")
+	texts := builder.NewRecord()
+	defer texts.Release()
+	if err := UnmarshalRecord(texts, &got); err == nil {
+		t.Errorf("want error decoding a column of another type")
+	}
+}
+
+type testUUID [16]byte
+
+func (u testUUID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(u[:])), nil
+}
+
+func TestNetworkTypes(t *testing.T) {
+	type UUID [16]byte
+	type conn struct {
+		ID      UUID             `json:"id"`
+		Trace   [16]byte         `json:"trace" arrow:"string"`
+		Session testUUID         `json:"session"`
+		Client  netip.Addr       `json:"client"`
+		Server  netip.Addr       `json:"server" arrow:"string"`
+		Subnet  netip.Prefix     `json:"subnet"`
+		MAC     net.HardwareAddr `json:"mac"`
+		Peers   []netip.Addr     `json:"peers"`
+	}
+	schema, err := DeriveArrowSchema(conn{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	want := []arrow.DataType{
+		NewUUIDType(),
+		arrow.BinaryTypes.String,
+		arrow.ListOfField(arrow.Field{Type: arrow.PrimitiveTypes.Int8, Nullable: true}),
+		&arrow.FixedSizeBinaryType{ByteWidth: 16},
+		arrow.BinaryTypes.String,
+		&arrow.FixedSizeBinaryType{ByteWidth: 17},
+		arrow.BinaryTypes.Binary,
+		arrow.ListOfField(arrow.Field{Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}, Nullable: true}),
+	}
+	for i, dt := range want {
+		if !arrow.TypeEqual(schema.Field(i).Type, dt) {
+			t.Errorf("column %s: want=%v, got=%v", schema.Field(i).Name, dt, schema.Field(i).Type)
+		}
+	}
+	// testUUID is neither unnamed nor named UUID, the schema is given
+	schema = arrow.NewSchema(append(schema.Fields()[:2:2],
+		arrow.Field{Name: "session", Type: NewUUIDType(), Nullable: true},
+		arrow.Field{Name: "client", Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}, Nullable: true},
+		arrow.Field{Name: "server", Type: arrow.BinaryTypes.String, Nullable: true},
+		arrow.Field{Name: "subnet", Type: &arrow.FixedSizeBinaryType{ByteWidth: 17}, Nullable: true},
+		arrow.Field{Name: "mac", Type: arrow.BinaryTypes.String, Nullable: true},
+		schema.Field(7),
+	), nil)
+
+	conns := []conn{
+		{
+			ID:      UUID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			Trace:   [16]byte{0xde, 0xad, 0xbe, 0xef},
+			Session: testUUID{0xff},
+			Client:  netip.MustParseAddr("10.0.0.1"),
+			Server:  netip.MustParseAddr("2001:db8::1"),
+			Subnet:  netip.MustParsePrefix("10.0.0.0/8"),
+			MAC:     net.HardwareAddr{0, 0x1b, 0x63, 0x84, 0x45, 0xe6},
+			Peers:   []netip.Addr{netip.MustParseAddr("::1"), netip.MustParseAddr("192.168.1.1")},
+		},
+		{Subnet: netip.MustParsePrefix("2001:db8::/32"), Peers: []netip.Addr{}},
+	}
+	record, err := MarshalRecord(conns, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	if v := record.Column(1).(*array.String).Value(0); v != "deadbeef-0000-0000-0000-000000000000" {
+		t.Errorf("unexpected trace column value: %s", v)
+	}
+	if v := record.Column(4).(*array.String).Value(0); v != "2001:db8::1" {
+		t.Errorf("unexpected server column value: %s", v)
+	}
+	if v := record.Column(6).(*array.String).Value(0); v != "00:1b:63:84:45:e6" {
+		t.Errorf("unexpected mac column value: %s", v)
+	}
+	for _, i := range []int{3, 4, 6} {
+		if !record.Column(i).IsNull(1) {
+			t.Errorf("column %s: want a null value for a zero value", schema.Field(i).Name)
+		}
+	}
+
+	// the extension type survives IPC, as it does in query results
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	if err := w.Write(record); err != nil {
+		t.Errorf("ipc write: %v", err)
+		return
+	}
+	w.Close()
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Errorf("ipc read: %v", err)
+		return
+	}
+	defer r.Release()
+	if !r.Next() {
+		t.Errorf("ipc read: %v", r.Err())
+		return
+	}
+	read := r.Record()
+	if dt, ok := read.Column(0).DataType().(*UUIDType); !ok || dt.ExtensionName() != "arrow.uuid" {
+		t.Errorf("want arrow.uuid extension type, got=%v", read.Column(0).DataType())
+	}
+
+	var got []conn
+	if err := UnmarshalRecord(read, &got); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(conns, got) {
+		t.Errorf("want=%+v, got=%+v", conns, got)
+	}
+
+	var rows []map[string]any
+	if err := UnmarshalRecord(read, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if id, ok := rows[0]["id"].([]byte); !ok || len(id) != 16 || id[15] != 16 {
+		t.Errorf("unexpected id value: %v", rows[0]["id"])
+	}
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema([]arrow.Field{{Name: "mac", Type: arrow.BinaryTypes.String, Nullable: true}}, nil))
+	defer builder.Release()
+	builder.Field(0).(*array.StringBuilder).Append("not a mac")
+	invalid := builder.NewRecord()
+	defer invalid.Release()
+	if err := UnmarshalRecord(invalid, &got); err == nil {
+		t.Errorf("want error decoding an invalid hardware address")
+	}
+}
+
+type testSemver struct {
+	Major, Minor, Patch int
+}
+
+var (
+	semverOnce sync.Once
+	semverErr  error
+)
+
+// registerSemver registers the test.semver extension once for all runs.
+func registerSemver() error {
+	semverOnce.Do(func() {
+		semverErr = RegisterExtension(Extension{
+			Name:     "test.semver",
+			Metadata: "v1",
+			GoType:   reflect.TypeOf(testSemver{}),
+			Storage:  arrow.BinaryTypes.String,
+			Serialize: func(b array.Builder, v any) error {
+				s := v.(testSemver)
+				if s.Major < 0 {
+					return fmt.Errorf("negative major version")
+				}
+				b.(*array.StringBuilder).Append(fmt.Sprintf("%d.%d.%d", s.Major, s.Minor, s.Patch))
+				return nil
+			},
+			Deserialize: func(arr arrow.Array, i int, v any) error {
+				s := v.(*testSemver)
+				_, err := fmt.Sscanf(arr.(*array.String).Value(i), "%d.%d.%d", &s.Major, &s.Minor, &s.Patch)
+				return err
+			},
+		})
+	})
+	return semverErr
+}
+
+type testBuild struct {
+	Number int
+}
+
+var (
+	buildOnce sync.Once
+	buildErr  error
+)
+
+// registerBuild registers testBuild under the name of the UUID extension type,
+// which arrow rejects, then registers it again under its own name.
+func registerBuild() error {
+	buildOnce.Do(func() {
+		ext := Extension{
+			Name:        "arrow.uuid",
+			GoType:      reflect.TypeOf(testBuild{}),
+			Storage:     arrow.PrimitiveTypes.Int64,
+			Serialize:   func(array.Builder, any) error { return nil },
+			Deserialize: func(arrow.Array, int, any) error { return nil },
+		}
+		if err := RegisterExtension(ext); err == nil {
+			buildErr = fmt.Errorf("want error registering arrow.uuid twice")
+			return
+		}
+		ext.Name = "test.build"
+		buildErr = RegisterExtension(ext)
+	})
+	return buildErr
+}
+
+func TestRegisterExtension(t *testing.T) {
+	if err := registerSemver(); err != nil {
+		t.Errorf("RegisterExtension: %v", err)
+		return
+	}
+	if err := RegisterExtension(Extension{Name: "test.semver2", GoType: reflect.TypeOf(testSemver{}), Storage: arrow.BinaryTypes.String,
+		Serialize: func(array.Builder, any) error { return nil }, Deserialize: func(arrow.Array, int, any) error { return nil }}); err == nil {
+		t.Errorf("want error registering a Go type twice")
+	}
+	if err := RegisterExtension(Extension{Name: "test.incomplete"}); err == nil {
+		t.Errorf("want error registering an incomplete extension")
+	}
+	if err := registerBuild(); err != nil {
+		t.Errorf("RegisterExtension after a failed registration: %v", err)
+	}
+
+	type pkg struct {
+		Name     string       `json:"name"`
+		Version  testSemver   `json:"version"`
+		Previous *testSemver  `json:"previous"`
+		Deps     []testSemver `json:"deps"`
+	}
+	schema, err := DeriveArrowSchema(pkg{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	for _, i := range []int{1, 2} {
+		dt, ok := schema.Field(i).Type.(arrow.ExtensionType)
+		if !ok || dt.ExtensionName() != "test.semver" || dt.Serialize() != "v1" {
+			t.Errorf("column %s: want test.semver extension type, got=%v", schema.Field(i).Name, schema.Field(i).Type)
+		}
+	}
+
+	// CreateFlight takes the schema serialized as an IPC stream
+	flightSchema, err := flight.DeserializeSchema(flight.SerializeSchema(schema, memory.DefaultAllocator), memory.DefaultAllocator)
+	if err != nil {
+		t.Errorf("DeserializeSchema: %v", err)
+		return
+	}
+	if !flightSchema.Equal(schema) {
+		t.Errorf("want=%v, got=%v", schema, flightSchema)
+	}
+
+	pkgs := []pkg{
+		{Name: "arrow", Version: testSemver{10, 0, 1}, Previous: &testSemver{9, 0, 0}, Deps: []testSemver{{1, 2, 3}}},
+		{Name: "client", Version: testSemver{0, 1, 0}, Deps: []testSemver{}},
+	}
+	record, err := MarshalRecord(pkgs, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	if v := record.Column(1).(array.ExtensionArray).Storage().(*array.String).Value(0); v != "10.0.1" {
+		t.Errorf("unexpected version column value: %s", v)
+	}
+	if !record.Column(2).IsNull(1) {
+		t.Errorf("want a null value for a nil pointer")
+	}
+
+	// query results are IPC streams as well
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	if err := w.Write(record); err != nil {
+		t.Errorf("ipc write: %v", err)
+		return
+	}
+	w.Close()
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Errorf("ipc read: %v", err)
+		return
+	}
+	defer r.Release()
+	if !r.Next() {
+		t.Errorf("ipc read: %v", r.Err())
+		return
+	}
+	if !r.Schema().Equal(schema) {
+		t.Errorf("want=%v, got=%v", schema, r.Schema())
+	}
+	var got []pkg
+	if err := UnmarshalRecord(r.Record(), &got); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(pkgs, got) {
+		t.Errorf("want=%+v, got=%+v", pkgs, got)
+	}
+
+	if _, err := MarshalRecord([]pkg{{Version: testSemver{Major: -1}}}, schema); err == nil || !strings.Contains(err.Error(), "negative major version") {
+		t.Errorf("want Serialize error, got=%v", err)
+	}
+
+	// columns of the storage type decode as well, those of other types fail
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema([]arrow.Field{
+		{Name: "version", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "previous", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	}, nil))
+	defer builder.Release()
+	builder.Field(0).(*array.StringBuilder).Append("1.2.3")
+	builder.Field(1).(*array.Int64Builder).AppendNull()
+	plain := builder.NewRecord()
+	defer plain.Release()
+	type versionOnly struct {
+		Version testSemver `json:"version"`
+	}
+	var versions []versionOnly
+	if err := UnmarshalRecord(plain, &versions); err != nil || versions[0].Version != (testSemver{1, 2, 3}) {
+		t.Errorf("unexpected versions: %v, %v", versions, err)
+	}
+	if err := UnmarshalRecord(plain, &got); err == nil {
+		t.Errorf("want error decoding a column of another type")
+	}
+
+	// a column of another extension type on the same storage fails even
+	// after its schema shares a fingerprint with a decoded one
+	other := &extensionType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.String}, name: "test.other"}
+	for _, dt := range []arrow.ExtensionType{schema.Field(1).Type.(arrow.ExtensionType), other} {
+		storage := array.NewStringBuilder(memory.DefaultAllocator)
+		storage.Append("1.2.3")
+		values := storage.NewArray()
+		column := array.NewExtensionArrayWithStorage(dt, values)
+		record := array.NewRecord(arrow.NewSchema([]arrow.Field{{Name: "version", Type: dt, Nullable: true}}, nil), []arrow.Array{column}, 1)
+		versions = nil
+		err := UnmarshalRecord(record, &versions)
+		record.Release()
+		column.Release()
+		values.Release()
+		storage.Release()
+		if dt == other && err == nil {
+			t.Errorf("want error decoding a column of extension type test.other")
+		} else if dt != other && (err != nil || versions[0].Version != (testSemver{1, 2, 3})) {
+			t.Errorf("unexpected versions: %v, %v", versions, err)
+		}
+	}
+}
+
+type testEvent interface {
+	isTestEvent()
+}
+
+type testLogEvent struct {
+	Message string `json:"message"`
+	Level   string `json:"level"`
+}
+
+type testMetricEvent struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+func (*testLogEvent) isTestEvent()   {}
+func (testMetricEvent) isTestEvent() {}
+
+type testEventOneof struct {
+	Log    *testLogEvent    `json:"log"`
+	Metric *testMetricEvent `json:"metric"`
+	Status *int32           `json:"status"`
+}
+
+func int32Ptr(v int32) *int32 { return &v }
+
+var (
+	eventUnionOnce sync.Once
+	eventUnionErr  error
+)
+
+// registerEventUnion registers the variants of testEvent once for all runs.
+func registerEventUnion() error {
+	eventUnionOnce.Do(func() {
+		eventUnionErr = RegisterUnion(reflect.TypeOf((*testEvent)(nil)).Elem(), map[arrow.UnionTypeCode]reflect.Type{
+			0: reflect.TypeOf(&testLogEvent{}),
+			1: reflect.TypeOf(testMetricEvent{}),
+		})
+	})
+	return eventUnionErr
+}
+
+func TestUnions(t *testing.T) {
+	if err := registerEventUnion(); err != nil {
+		t.Errorf("RegisterUnion: %v", err)
+		return
+	}
+	if err := RegisterUnion(reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), map[arrow.UnionTypeCode]reflect.Type{0: reflect.TypeOf(0)}); err == nil {
+		t.Errorf("want error registering a variant not implementing the interface")
+	}
+
+	type row struct {
+		ID     int             `json:"id"`
+		Event  testEventOneof  `json:"event" arrow:"oneof"`
+		Sparse *testEventOneof `json:"sparse" arrow:"oneof(sparse)"`
+	}
+	schema, err := DeriveArrowSchema(row{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	dense, ok := schema.Field(1).Type.(*arrow.DenseUnionType)
+	if !ok || len(dense.Fields()) != 3 || dense.Fields()[1].Name != "metric" || !reflect.DeepEqual(dense.TypeCodes(), []arrow.UnionTypeCode{0, 1, 2}) {
+		t.Errorf("want dense union of log, metric and status, got=%v", schema.Field(1).Type)
+	}
+	if _, ok := schema.Field(2).Type.(*arrow.SparseUnionType); !ok {
+		t.Errorf("want sparse union, got=%v", schema.Field(2).Type)
+	}
+
+	rows := []row{
+		{ID: 1, Event: testEventOneof{Log: &testLogEvent{Message: "started", Level: "info"}}, Sparse: &testEventOneof{Status: int32Ptr(200)}},
+		{ID: 2, Event: testEventOneof{Metric: &testMetricEvent{Name: "latency", Value: 0.25}}},
+		{ID: 3, Sparse: &testEventOneof{Metric: &testMetricEvent{Name: "errors", Value: 2}}},
+		// a zero value is a value, not a null
+		{ID: 4, Event: testEventOneof{Status: int32Ptr(0)}},
+	}
+	record, err := MarshalRecord(rows, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	if codes := record.Column(1).(*array.DenseUnion).RawTypeCodes(); !reflect.DeepEqual(codes, []arrow.UnionTypeCode{0, 1, 0, 2}) {
+		t.Errorf("unexpected type codes: %v", codes)
+	}
+	var got []row
+	if err := UnmarshalRecord(record, &got); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows, got) {
+		t.Errorf("want=%+v, got=%+v", rows, got)
+	}
+
+	var values []map[string]any
+	if err := UnmarshalRecord(record, &values); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	want := []any{
+		map[string]any{"message": "started", "level": "info"},
+		map[string]any{"name": "latency", "value": 0.25},
+		nil,
+		int64(0),
+	}
+	for i, v := range values {
+		if !reflect.DeepEqual(v["event"], want[i]) {
+			t.Errorf("row %d: want=%v, got=%v", i, want[i], v["event"])
+		}
+	}
+	if values[0]["sparse"] != int64(200) {
+		t.Errorf("unexpected sparse value: %v", values[0]["sparse"])
+	}
+
+	// oneof fields are pointers, so that zero values are not taken for nulls
+	type plainOneof struct {
+		Count int32 `json:"count"`
+	}
+	type plainRow struct {
+		Event plainOneof `json:"event" arrow:"oneof"`
+	}
+	if _, err := DeriveArrowSchema(plainRow{}, nil); err == nil || !strings.Contains(err.Error(), "not a pointer") {
+		t.Errorf("want error deriving a oneof struct of non-pointer fields, got=%v", err)
+	}
+	plainSchema := arrow.NewSchema([]arrow.Field{{Name: "event", Type: arrow.DenseUnionOf([]arrow.Field{
+		{Name: "count", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+	}, []arrow.UnionTypeCode{0}), Nullable: true}}, nil)
+	if _, err := MarshalRecord([]plainRow{{}}, plainSchema); err == nil {
+		t.Errorf("want error encoding a oneof struct of non-pointer fields")
+	}
+
+	// the interface decodes the variant of each type code
+	type eventRow struct {
+		Event testEvent `json:"event"`
+	}
+	unionSchema := arrow.NewSchema([]arrow.Field{{Name: "event", Type: arrow.DenseUnionOf(dense.Fields()[:2], []arrow.UnionTypeCode{0, 1}), Nullable: true}}, nil)
+	events := []eventRow{
+		{Event: &testLogEvent{Message: "stopped", Level: "warn"}},
+		{Event: testMetricEvent{Name: "cpu", Value: 0.5}},
+		{},
+	}
+	record, err = MarshalRecord(events, unionSchema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+	var gotEvents []eventRow
+	if err := UnmarshalRecord(record, &gotEvents); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(events, gotEvents) {
+		t.Errorf("want=%+v, got=%+v", events, gotEvents)
+	}
+	unregistered := arrow.NewSchema([]arrow.Field{{Name: "event", Type: arrow.DenseUnionOf([]arrow.Field{
+		{Name: "trace", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "metric", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	}, []arrow.UnionTypeCode{5, 1}), Nullable: true}}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, unregistered)
+	defer builder.Release()
+	empty := builder.NewRecord()
+	defer empty.Release()
+	if err := UnmarshalRecord(empty, &gotEvents); err == nil || !strings.Contains(err.Error(), "event.trace") {
+		t.Errorf("want error for a type code without variant, got=%v", err)
+	}
+}
+
+func TestUnionsNested(t *testing.T) {
+	type inner struct {
+		Event testEventOneof `json:"event" arrow:"oneof(sparse)"`
+	}
+	type derivedRow struct {
+		Events []testEventOneof `json:"events" arrow:"oneof"`
+		Inner  *inner           `json:"inner"`
+	}
+	type row struct {
+		derivedRow
+		ByName map[string]testEventOneof `json:"by_name"`
+	}
+	// Go maps do not derive map columns, the map column is set up by hand
+	derived, err := DeriveArrowSchema(derivedRow{}, nil)
+	if err != nil {
+		t.Errorf("DeriveArrowSchema: %v", err)
+		return
+	}
+	union := derived.Field(0).Type.(*arrow.ListType).Elem()
+	schema := arrow.NewSchema([]arrow.Field{
+		derived.Field(0),
+		derived.Field(1),
+		{Name: "by_name", Type: arrow.MapOf(arrow.BinaryTypes.String, union), Nullable: true},
+	}, nil)
+
+	rows := []row{
+		{
+			derivedRow: derivedRow{
+				Events: []testEventOneof{
+					{Log: &testLogEvent{Message: "started", Level: "info"}},
+					{Metric: &testMetricEvent{Name: "latency", Value: 0.25}},
+				},
+				Inner: &inner{Event: testEventOneof{Log: &testLogEvent{Message: "nested", Level: "debug"}}},
+			},
+			ByName: map[string]testEventOneof{"cpu": {Metric: &testMetricEvent{Name: "cpu", Value: 0.5}}},
+		},
+		// null lists decode into empty slices
+		{derivedRow: derivedRow{Events: []testEventOneof{}}},
+		{derivedRow: derivedRow{Events: []testEventOneof{{Status: int32Ptr(503)}, {Status: int32Ptr(0)}}, Inner: &inner{Event: testEventOneof{Status: int32Ptr(0)}}}},
+	}
+	record, err := MarshalRecord(rows, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	if err := w.Write(record); err != nil {
+		t.Errorf("ipc write: %v", err)
+		return
+	}
+	w.Close()
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Errorf("ipc read: %v", err)
+		return
+	}
+	defer r.Release()
+	if !r.Next() {
+		t.Errorf("ipc read: %v", r.Err())
+		return
+	}
+	var got []row
+	if err := UnmarshalRecord(r.Record(), &got); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows, got) {
+		t.Errorf("want=%+v, got=%+v", rows, got)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	type httpAttrs struct {
+		Status int32  `json:"status"`
+		Method string `json:"method"`
+	}
+	type attrs struct {
+		HTTP *httpAttrs `json:"http"`
+	}
+	type span struct {
+		Name       string            `json:"name"`
+		Attributes attrs             `json:"attributes"`
+		Tags       map[string]string `json:"tags"`
+	}
+	type trace struct {
+		ID    string `json:"id"`
+		Spans []span `json:"spans"`
+	}
+	traces := []trace{
+		{ID: "a", Spans: []span{
+			{Name: "get", Attributes: attrs{HTTP: &httpAttrs{Status: 200, Method: "GET"}}, Tags: map[string]string{"env": "prod"}},
+			{Name: "db", Tags: map[string]string{}},
+			{Name: "post", Attributes: attrs{HTTP: &httpAttrs{Status: 500, Method: "POST"}}},
+		}},
+		{ID: "b", Spans: []span{}},
+		{ID: "c", Spans: []span{{Name: "put", Attributes: attrs{HTTP: &httpAttrs{Status: 201}}, Tags: map[string]string{"env": "dev"}}}},
+	}
+	httpType := arrow.StructOf(
+		arrow.Field{Name: "status", Type: arrow.PrimitiveTypes.Int32},
+		arrow.Field{Name: "method", Type: arrow.BinaryTypes.String, Nullable: true},
+	)
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.BinaryTypes.String},
+		{Name: "spans", Type: arrow.ListOf(arrow.StructOf(
+			arrow.Field{Name: "name", Type: arrow.BinaryTypes.String},
+			arrow.Field{Name: "attributes", Type: arrow.StructOf(arrow.Field{Name: "http", Type: httpType, Nullable: true})},
+			arrow.Field{Name: "tags", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.BinaryTypes.String), Nullable: true},
+		)), Nullable: true},
+	}, nil)
+	record, err := MarshalRecord(traces, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	// a null struct along the path matches nothing
+	var statuses []int
+	if err := Extract(record, "$.spans[*].attributes.http.status", &statuses); err != nil || !reflect.DeepEqual(statuses, []int{200, 500, 201}) {
+		t.Errorf("unexpected statuses: %v, %v", statuses, err)
+	}
+
+	var first string
+	if err := Extract(record, "$.spans[1].name", &first); err != nil || first != "db" {
+		t.Errorf("unexpected first match: %q, %v", first, err)
+	}
+	var envs []string
+	if err := Extract(record, "$.spans[*].tags.env", &envs); err != nil || !reflect.DeepEqual(envs, []string{"prod", "dev"}) {
+		t.Errorf("unexpected envs: %v, %v", envs, err)
+	}
+	var methods []any
+	if err := Extract(record, "$..method", &methods); err != nil || !reflect.DeepEqual(methods, []any{"GET", "POST", ""}) {
+		t.Errorf("unexpected methods: %v, %v", methods, err)
+	}
+	var https []httpAttrs
+	if err := Extract(record, "$.spans[0].attributes.http", &https); err != nil || !reflect.DeepEqual(https, []httpAttrs{{200, "GET"}, {201, ""}}) {
+		t.Errorf("unexpected http attributes: %v, %v", https, err)
+	}
+	var rows []trace
+	if err := Extract(record, "$", &rows); err != nil || !reflect.DeepEqual(rows[0], traces[0]) || len(rows) != 3 {
+		t.Errorf("unexpected rows: %v, %v", rows, err)
+	}
+
+	// a list matched is one value, extracted into a slice of slices
+	var spanLists [][]span
+	if err := Extract(record, "$.spans", &spanLists); err != nil || len(spanLists) != 3 || !reflect.DeepEqual(spanLists[0], traces[0].Spans) {
+		t.Errorf("unexpected span lists: %v, %v", spanLists, err)
+	}
+	var spans []span
+	if err := Extract(record, "$.spans", &spans); err == nil || !strings.Contains(err.Error(), "[*]") {
+		t.Errorf("want error extracting lists into a slice of their elements, got=%v", err)
+	}
+
+	if err := Extract(record, "$.spans[*].duration", &statuses); err == nil {
+		t.Errorf("want error for a field of no column")
+	}
+	if err := Extract(record, "$.id[0]", &statuses); err == nil {
+		t.Errorf("want error indexing a string column")
+	}
+	var names []int
+	if err := Extract(record, "$.spans[*].name", &names); err == nil {
+		t.Errorf("want error decoding strings into ints")
+	}
+	if err := Extract(record, "spans", &names); err == nil {
+		t.Errorf("want error for an invalid path")
+	}
+	if err := Extract(record, "$.id", names); err == nil {
+		t.Errorf("want error for a non-pointer value")
+	}
+}
+
+func TestUnmarshalRecordMaps(t *testing.T) {
+	record := newBenchRecord(2)
+	defer record.Release()
+
+	var rows []map[string]any
+	if err := UnmarshalRecord(record, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	want := map[string]any{
+		"duration": int64(1),
+		"service":  "checkout",
+		"status":   int64(201),
+		"latency":  float64(1) / 3,
+		"error":    false,
+		"tags":     []any{"1"},
+	}
+	if len(rows) != 2 || !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	var statuses []map[string]int
+	if err := UnmarshalRecord(record, &statuses); err == nil {
+		t.Errorf("want error for map value type mismatch")
+	}
+}
+
+func TestMarshalRecord(t *testing.T) {
+	record := newBenchRecord(4)
+	defer record.Release()
+
+	var spans []benchSpan
+	if err := UnmarshalRecord(record, &spans); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	encoded, err := MarshalRecord(spans, record.Schema())
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer encoded.Release()
+	if !array.RecordEqual(record, encoded) {
+		t.Errorf("want=%v, got=%v", record, encoded)
+	}
+
+	rows := []map[string]any{{"service": "frontend", "status": 200, "tags": []string{"a"}}}
+	encoded, err = MarshalRecord(rows, record.Schema())
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer encoded.Release()
+	if !encoded.Column(0).IsNull(0) || encoded.Column(2).(*array.Int32).Value(0) != 200 {
+		t.Errorf("unexpected record: %v", encoded)
+	}
+
+	type narrow struct {
+		Status int64 `json:"status"`
+	}
+	schema := arrow.NewSchema([]arrow.Field{{Name: "status", Type: arrow.PrimitiveTypes.Int8, Nullable: true}}, nil)
+	_, err = MarshalRecord([]narrow{{Status: 1}, {Status: 300}}, schema)
+	if e, ok := err.(*MarshalTypeError); !ok || e.Column != "status" || e.Row != 1 {
+		t.Errorf("want *MarshalTypeError for status at row 1, got=%v", err)
+	}
+	if _, err := MarshalRecord(narrow{}, schema); err == nil {
+		t.Errorf("want error for non-slice value")
+	}
+}
+
+func TestRows(t *testing.T) {
+	record := newBenchRecord(3)
+	defer record.Release()
+
+	rows := Rows(record)
+	if len(rows) != 3 {
+		t.Errorf("want 3 rows, got=%d", len(rows))
+		return
+	}
+	if v, ok := rows[2].String("service"); !ok || v != "payment" {
+		t.Errorf("unexpected service: %v", v)
+	}
+	if v, ok := rows[2].Int64("status"); !ok || v != 202 {
+		t.Errorf("unexpected status: %v", v)
+	}
+	if v, ok := rows[2].Get("tags[0]"); !ok || v != "2" {
+		t.Errorf("unexpected tag: %v", v)
+	}
+	if _, ok := rows[2].Get("tags[1]"); ok {
+		t.Errorf("want no value past the end of a list")
+	}
+	if _, ok := rows[2].Int64("service"); ok {
+		t.Errorf("want no int64 value for a string column")
+	}
+	if _, ok := rows[0].Time("missing"); ok {
+		t.Errorf("want no value for a missing column")
+	}
+}
@@ -1,58 +1,101 @@
-// Copyright 2011 The Go Authors. All rights reserved.
-// Use of this source code is governed by a BSD-style
-// license that can be found in the LICENSE file.
 package client
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
-	"unicode"
-)
-
-// encoding/json/tags.go
 
-// tagOptions is the string following a comma in a struct field's "json"
-// tag, or the empty string. It does not include the leading comma.
-type tagOptions string
+	"github.com/apache/arrow/go/v10/arrow"
+)
 
-// parseTag splits a struct field's json tag into its name and
-// comma-separated options.
-func parseTag(tag string) (string, tagOptions) {
-	tag, opt, _ := strings.Cut(tag, ",")
-	return tag, tagOptions(opt)
+// arrowTagOptions holds the column type named by a struct field's "arrow" tag.
+type arrowTagOptions struct {
+	// kind is one of decimal, duration, date32, date64, time32, time64,
+	// timestamp, string and oneof, or empty without tag.
+	kind      string
+	precision int32
+	scale     int32
+	unit      arrow.TimeUnit
+	mode      arrow.UnionMode
 }
 
-// Contains reports whether a comma-separated list of options
-// contains a particular substr flag. substr must be surrounded by a
-// string boundary or commas.
-func (o tagOptions) Contains(optionName string) bool {
-	if len(o) == 0 {
-		return false
+// parseArrowTag parses a struct field's arrow tag, one of
+//
+//	decimal(precision,scale), or decimal(precision) for scale 0
+//	duration(unit), with unit s, ms, us or ns and ns by default
+//	timestamp(unit), ms by default
+//	time32(unit), s or ms and ms by default
+//	time64(unit), us or ns and ns by default
+//	date32 and date64
+//	string, for UUIDs and network addresses stored as text
+//	oneof(mode), for structs of pointer fields holding the value in the
+//	non-nil one, with mode dense or sparse and dense by default
+func parseArrowTag(tag string) (arrowTagOptions, error) {
+	if tag == "" {
+		return arrowTagOptions{}, nil
 	}
-	s := string(o)
-	for s != "" {
-		var name string
-		name, s, _ = strings.Cut(s, ",")
-		if name == optionName {
-			return true
+	kind, args, hasArgs := strings.Cut(tag, "(")
+	if hasArgs {
+		if !strings.HasSuffix(args, ")") {
+			return arrowTagOptions{}, fmt.Errorf("invalid arrow tag %q", tag)
 		}
+		args = strings.TrimSpace(strings.TrimSuffix(args, ")"))
 	}
-	return false
-}
 
-// encoding/json/encoder.go
-func isValidTag(s string) bool {
-	if s == "" {
-		return false
-	}
-	for _, c := range s {
-		switch {
-		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
-			// Backslash and quote chars are reserved, but
-			// otherwise any punctuation chars are allowed
-			// in a tag name.
-		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
-			return false
+	opts := arrowTagOptions{kind: kind}
+	switch kind {
+	case "decimal":
+		precision, scale, hasScale := strings.Cut(args, ",")
+		p, err := strconv.ParseInt(strings.TrimSpace(precision), 10, 32)
+		if err != nil {
+			return opts, fmt.Errorf("invalid decimal precision in arrow tag %q", tag)
+		}
+		var sc int64
+		if hasScale {
+			if sc, err = strconv.ParseInt(strings.TrimSpace(scale), 10, 32); err != nil {
+				return opts, fmt.Errorf("invalid decimal scale in arrow tag %q", tag)
+			}
+		}
+		if p < 1 || p > maxDecimal256Precision || sc < 0 || sc > p {
+			return opts, fmt.Errorf("decimal precision or scale out of range in arrow tag %q", tag)
+		}
+		opts.precision, opts.scale = int32(p), int32(sc)
+	case "duration", "timestamp", "time32", "time64":
+		units := map[string][]arrow.TimeUnit{
+			"duration":  {arrow.Nanosecond, arrow.Second, arrow.Millisecond, arrow.Microsecond},
+			"timestamp": {arrow.Millisecond, arrow.Second, arrow.Microsecond, arrow.Nanosecond},
+			"time32":    {arrow.Millisecond, arrow.Second},
+			"time64":    {arrow.Nanosecond, arrow.Microsecond},
+		}[kind]
+		// the first unit is the default
+		opts.unit = units[0]
+		if args == "" {
+			break
+		}
+		valid := false
+		for _, u := range units {
+			if u.String() == args {
+				opts.unit, valid = u, true
+			}
+		}
+		if !valid {
+			return opts, fmt.Errorf("invalid time unit in arrow tag %q", tag)
+		}
+	case "date32", "date64", "string":
+		if hasArgs {
+			return opts, fmt.Errorf("invalid arrow tag %q", tag)
+		}
+	case "oneof":
+		switch args {
+		case "", "dense":
+			opts.mode = arrow.DenseMode
+		case "sparse":
+			opts.mode = arrow.SparseMode
+		default:
+			return opts, fmt.Errorf("invalid union mode in arrow tag %q", tag)
 		}
+	default:
+		return opts, fmt.Errorf("unknown arrow tag %q", tag)
 	}
-	return true
+	return opts, nil
 }
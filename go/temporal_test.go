@@ -0,0 +1,286 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/chronowave/fbs/go"
+)
+
+type timing struct {
+	Latency  time.Duration              `json:"latency" arrow:"duration(ms)"`
+	Elapsed  time.Duration              `json:"elapsed"`
+	Opens    time.Time                  `json:"opens" arrow:"time32(s)"`
+	Closes   time.Duration              `json:"closes" arrow:"time64(us)"`
+	Day      time.Time                  `json:"day" arrow:"date64"`
+	Period   arrow.MonthDayNanoInterval `json:"period"`
+	Retries  []time.Duration            `json:"retries" arrow:"duration(s)"`
+	Received time.Time                  `json:"received" arrow:"timestamp(us)"`
+}
+
+func TestDeriveArrowSchemaTemporal(t *testing.T) {
+	schema, err := DeriveArrowSchema(timing{}, nil)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	want := []arrow.DataType{
+		&arrow.DurationType{Unit: arrow.Millisecond},
+		&arrow.DurationType{Unit: arrow.Nanosecond},
+		&arrow.Time32Type{Unit: arrow.Second},
+		&arrow.Time64Type{Unit: arrow.Microsecond},
+		arrow.FixedWidthTypes.Date64,
+		arrow.FixedWidthTypes.MonthDayNanoInterval,
+		arrow.ListOfField(arrow.Field{Type: &arrow.DurationType{Unit: arrow.Second}, Nullable: true}),
+		&arrow.TimestampType{Unit: arrow.Microsecond},
+	}
+	for i, f := range schema.Fields() {
+		if !arrow.TypeEqual(f.Type, want[i]) {
+			t.Errorf("field %s: want=%v, got=%v", f.Name, want[i], f.Type)
+		}
+	}
+
+	// time32 holds seconds or milliseconds only
+	if _, err := DeriveArrowSchema(struct {
+		D time.Duration `arrow:"time32(us)"`
+	}{}, nil); err == nil {
+		t.Errorf("want error for time32(us)")
+	}
+
+	for _, v := range []any{
+		struct {
+			T time.Time `arrow:"duration"`
+		}{},
+		struct {
+			I int64 `arrow:"date32"`
+		}{},
+	} {
+		if _, err := DeriveArrowSchema(v, nil); err == nil || !strings.Contains(err.Error(), "does not apply") {
+			t.Errorf("want error for %T, got %v", v, err)
+		}
+	}
+}
+
+func TestUnmarshalRecordTemporal(t *testing.T) {
+	schema, _ := DeriveArrowSchema(timing{}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	period := arrow.MonthDayNanoInterval{Months: 1, Days: 2, Nanoseconds: 3}
+	builder.Field(0).(*array.DurationBuilder).Append(1500)
+	builder.Field(1).(*array.DurationBuilder).Append(arrow.Duration(time.Second))
+	builder.Field(2).(*array.Time32Builder).Append(arrow.Time32(9 * 3600))
+	builder.Field(3).(*array.Time64Builder).Append(arrow.Time64(17 * time.Hour / time.Microsecond))
+	builder.Field(4).(*array.Date64Builder).Append(arrow.Date64FromTime(day))
+	builder.Field(5).(*array.MonthDayNanoIntervalBuilder).Append(period)
+	retries := builder.Field(6).(*array.ListBuilder)
+	retries.Append(true)
+	retries.ValueBuilder().(*array.DurationBuilder).AppendValues([]arrow.Duration{1, 2}, nil)
+	builder.Field(7).(*array.TimestampBuilder).Append(arrow.Timestamp(day.UnixMicro()))
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var rows []timing
+	if err := UnmarshalRecord(record, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	got := rows[0]
+	if got.Latency != 1500*time.Millisecond || got.Elapsed != time.Second || got.Closes != 17*time.Hour {
+		t.Errorf("unexpected durations: %+v", got)
+	}
+	if got.Opens.Hour() != 9 || !got.Day.Equal(day) || !got.Received.Equal(day) {
+		t.Errorf("unexpected times: %+v", got)
+	}
+	if got.Period != period || len(got.Retries) != 2 || got.Retries[1] != 2*time.Second {
+		t.Errorf("unexpected values: %+v", got)
+	}
+
+	var ints []struct {
+		Latency int64 `json:"latency"`
+	}
+	if _, ok := UnmarshalRecord(record, &ints).(*UnmarshalTypeError); !ok {
+		t.Errorf("want *UnmarshalTypeError for duration into int64")
+	}
+
+	var values []map[string]any
+	if err := UnmarshalRecord(record, &values); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if values[0]["latency"] != 1500*time.Millisecond || values[0]["period"] != period {
+		t.Errorf("unexpected values: %v", values[0])
+	}
+}
+
+func newTimestampRecord(tz string) arrow.Record {
+	dt := &arrow.TimestampType{Unit: arrow.Second, TimeZone: tz}
+	schema := arrow.NewSchema([]arrow.Field{{Name: "at", Type: dt, Nullable: true}}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.TimestampBuilder).Append(1700000000)
+	return builder.NewRecord()
+}
+
+func TestUnmarshalRecordTimeLocation(t *testing.T) {
+	record := newTimestampRecord("America/New_York")
+	defer record.Release()
+
+	type event struct {
+		At time.Time `json:"at"`
+	}
+	for loc, want := range map[TimeLocation]string{
+		ColumnTimeLocation: "America/New_York",
+		UTCTimeLocation:    "UTC",
+		LocalTimeLocation:  "Local",
+	} {
+		var events []event
+		if err := UnmarshalRecordWithOptions(record, &events, DecodeTimeLocation(loc)); err != nil {
+			t.Errorf("UnmarshalRecordWithOptions: %v", err)
+			continue
+		}
+		if got := events[0].At.Location().String(); got != want || events[0].At.Unix() != 1700000000 {
+			t.Errorf("location %d: want=%s, got=%s", loc, want, got)
+		}
+
+		var values []map[string]any
+		if err := UnmarshalRecordWithOptions(record, &values, DecodeTimeLocation(loc)); err != nil {
+			t.Errorf("UnmarshalRecordWithOptions: %v", err)
+			continue
+		}
+		if got := values[0]["at"].(time.Time).Location().String(); got != want {
+			t.Errorf("location %d: want=%s, got=%s", loc, want, got)
+		}
+	}
+
+	invalid := newTimestampRecord("Mars/Olympus_Mons")
+	defer invalid.Release()
+	var events []event
+	if err := UnmarshalRecordWithOptions(invalid, &events, DecodeTimeLocation(UTCTimeLocation)); err == nil {
+		t.Errorf("want error for invalid time zone")
+	}
+	// values read without a plan fail alike
+	row := Rows(invalid)[0]
+	if v, ok := row.Get("at"); ok {
+		t.Errorf("want no value for invalid time zone, got %v", v)
+	}
+	if v, ok := row.Time("at"); ok {
+		t.Errorf("want no time for invalid time zone, got %v", v)
+	}
+}
+
+type layouts struct {
+	Day    time.Time   `json:"day"`
+	Seen   time.Time   `json:"seen"`
+	Stamps []time.Time `json:"stamps"`
+}
+
+func layoutSchema() *arrow.Schema {
+	layout := func(v string) arrow.Metadata {
+		return arrow.NewMetadata([]string{fbs.EnumNamesMetadataKey[fbs.MetadataKeyLAYOUT]}, []string{v})
+	}
+	return arrow.NewSchema([]arrow.Field{
+		{Name: "day", Type: arrow.BinaryTypes.String, Nullable: true, Metadata: layout("2006-01-02")},
+		{Name: "seen", Type: arrow.PrimitiveTypes.Int64, Nullable: true, Metadata: layout("unix")},
+		{Name: "stamps", Type: arrow.ListOf(arrow.BinaryTypes.String), Nullable: true, Metadata: layout(time.Kitchen)},
+	}, nil)
+}
+
+func TestUnmarshalRecordTimeLayout(t *testing.T) {
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, layoutSchema())
+	defer builder.Release()
+	builder.Field(0).(*array.StringBuilder).AppendValues([]string{"2024-03-01", "March 1st"}, nil)
+	builder.Field(1).(*array.Int64Builder).AppendValues([]int64{1700000000, 0}, nil)
+	stamps := builder.Field(2).(*array.ListBuilder)
+	stamps.Append(true)
+	stamps.ValueBuilder().(*array.StringBuilder).Append("3:04PM")
+	stamps.AppendNull()
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var rows []layouts
+	err := UnmarshalRecord(record, &rows)
+	if e, ok := err.(*UnmarshalTypeError); !ok || e.Column != "day" || e.Row != 1 {
+		t.Errorf("want *UnmarshalTypeError for day at row 1, got %v", err)
+	}
+
+	one := record.NewSlice(0, 1)
+	defer one.Release()
+	if err := UnmarshalRecord(one, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	got := rows[0]
+	if !got.Day.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || got.Seen.Unix() != 1700000000 {
+		t.Errorf("unexpected times: %+v", got)
+	}
+	if len(got.Stamps) != 1 || got.Stamps[0].Hour() != 15 || got.Stamps[0].Minute() != 4 {
+		t.Errorf("unexpected stamps: %+v", got.Stamps)
+	}
+}
+
+func TestMarshalRecordTimeLayout(t *testing.T) {
+	day := time.Date(2024, 3, 1, 15, 4, 0, 0, time.UTC)
+	record, err := MarshalRecord([]layouts{{Day: day, Seen: day, Stamps: []time.Time{day}}, {}}, layoutSchema())
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	if got := record.Column(0).(*array.String).Value(0); got != "2024-03-01" {
+		t.Errorf("want day=2024-03-01, got %s", got)
+	}
+	if got := record.Column(1).(*array.Int64).Value(0); got != day.Unix() {
+		t.Errorf("want seen=%d, got %d", day.Unix(), got)
+	}
+	if !record.Column(2).IsNull(1) {
+		t.Errorf("want null stamps for nil slice")
+	}
+
+	var rows []layouts
+	if err := UnmarshalRecord(record, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !rows[0].Seen.Equal(day) || rows[0].Stamps[0].Format(time.Kitchen) != "3:04PM" {
+		t.Errorf("unexpected round trip: %+v", rows[0])
+	}
+}
+
+func TestMarshalRecordTemporal(t *testing.T) {
+	schema, _ := DeriveArrowSchema(timing{}, nil)
+	want := timing{
+		Latency:  1500 * time.Millisecond,
+		Elapsed:  time.Second,
+		Opens:    time.Date(1970, 1, 1, 9, 0, 0, 0, time.UTC),
+		Closes:   17 * time.Hour,
+		Day:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Period:   arrow.MonthDayNanoInterval{Months: 1, Days: 2, Nanoseconds: 3},
+		Retries:  []time.Duration{time.Second, 2 * time.Second},
+		Received: time.Date(2024, 3, 1, 12, 0, 0, 1000, time.UTC),
+	}
+	record, err := MarshalRecord([]timing{want}, schema)
+	if err != nil {
+		t.Errorf("MarshalRecord: %v", err)
+		return
+	}
+	defer record.Release()
+
+	var rows []timing
+	if err := UnmarshalRecord(record, &rows); err != nil {
+		t.Errorf("UnmarshalRecord: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("want=%+v, got=%+v", want, rows[0])
+	}
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"reflect"
+
+	"github.com/apache/arrow/go/v10/arrow"
+
+	"github.com/chronowave/client/go/internal/decode"
+)
+
+// RegisterUnion registers the variants of the interface type iface, for
+// UnmarshalRecord to decode the values of union columns into iface as the
+// variant of their type code, and MarshalRecord to encode the variants into
+// the children of their type code. Union columns also decode into any, as
+// the values of their children, and into structs with a field per child, as
+// DeriveArrowSchema maps fields with the oneof tag.
+//
+// Unions are registered before iface is first encoded or decoded, typically
+// in an init function.
+func RegisterUnion(iface reflect.Type, variants map[arrow.UnionTypeCode]reflect.Type) error {
+	return decode.RegisterUnion(iface, variants)
+}